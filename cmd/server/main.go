@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"csv-processor/internal/config"
 	"csv-processor/internal/handlers"
+	"csv-processor/internal/metrics"
+	"csv-processor/internal/results"
 	"csv-processor/internal/services"
 )
 
@@ -36,28 +41,121 @@ func main() {
 	if _, err := os.Stat(config.GetDataFilePath(csvConfig.CompetitionData)); os.IsNotExist(err) {
 		log.Fatalf("Competition CSV file not found at: %s", config.GetDataFilePath(csvConfig.CompetitionData))
 	}
+	// Criminality data is optional, /readyz reports it when it is missing
+	if _, err := os.Stat(config.GetDataFilePath(csvConfig.CommuneCrimes)); os.IsNotExist(err) {
+		log.Printf("Warning: commune crimes CSV file not found at: %s", config.GetDataFilePath(csvConfig.CommuneCrimes))
+	}
+	if _, err := os.Stat(config.GetDataFilePath(csvConfig.DepartmentCrimes)); os.IsNotExist(err) {
+		log.Printf("Warning: department crimes CSV file not found at: %s", config.GetDataFilePath(csvConfig.DepartmentCrimes))
+	}
 
 	log.Printf("Using business CSV file at: %s", config.GetDataFilePath(csvConfig.BusinessData))
 	log.Printf("Using IRIS CSV file at: %s", config.GetDataFilePath(csvConfig.IrisData))
 	log.Printf("Using QP CSV file at: %s", config.GetDataFilePath(csvConfig.QPData))
 	log.Printf("Using commune CSV file at: %s", config.GetDataFilePath(csvConfig.CommuneData))
 	log.Printf("Using competition CSV file at: %s", config.GetDataFilePath(csvConfig.CompetitionData))
+	log.Printf("Using commune crimes CSV file at: %s", config.GetDataFilePath(csvConfig.CommuneCrimes))
+	log.Printf("Using department crimes CSV file at: %s", config.GetDataFilePath(csvConfig.DepartmentCrimes))
+
+	// Generations of datasets share the worker pool computing zone intersections
+	workerPool := services.NewWorkerPool(config.GetWorkerPoolSize())
+	log.Printf("Worker pool started with %d workers", workerPool.Size())
+	datasets := services.NewDatasets(workerPool)
+	defer datasets.Close()
+
+	// Results are stored until the server has stopped
+	resultsConfig := config.GetResultsConfig()
+	resultStore, err := results.New(resultsConfig)
+	if err != nil {
+		log.Fatalf("Error opening result store: %v", err)
+	}
+	defer resultStore.Close()
+	log.Printf("Storing results with the %s backend", resultsConfig.Backend)
+
+	// Set up routes, data endpoints answer 503 until the datasets are loaded
+	healthHandler := handlers.NewHealthHandler(datasets)
+	adminHandler := handlers.NewAdminHandler(datasets)
+	searchHandler := handlers.NewSearchHandler(datasets, resultStore)
+	irisHandler := handlers.NewIrisHandler(datasets, resultStore)
+	resultsHandler := handlers.NewResultsHandler(resultStore)
+
+	mux := http.NewServeMux()
+	handlers.Route(mux, http.MethodGet, "/healthz", "healthz", healthHandler.HandleHealthz)
+	handlers.Route(mux, http.MethodGet, "/readyz", "readyz", healthHandler.HandleReadyz)
+	handlers.Route(mux, http.MethodGet, "/datasets", "datasets", healthHandler.HandleDatasets)
+	handlers.Route(mux, http.MethodGet, "/metrics", "metrics", metrics.Handler().ServeHTTP)
+	handlers.Route(mux, http.MethodPost, "/admin/reload", "admin_reload", adminHandler.HandleReload)
+	handlers.Route(mux, http.MethodPost, "/competitor-search", "competitor_search", searchHandler.HandleSearch)
+	handlers.Route(mux, http.MethodPost, "/competitor-count", "competitor_count", searchHandler.HandleCompetitorCount)
+	handlers.Route(mux, http.MethodPost, "/competition-data", "competition_data", searchHandler.HandleCompetitionData)
+	handlers.Route(mux, http.MethodPost, "/iris-data", "iris_data", irisHandler.HandleIrisData)
+	handlers.Route(mux, http.MethodGet, "/iris-data/metrics", "iris_metrics", irisHandler.HandleIrisMetrics)
+	handlers.Route(mux, http.MethodGet, "/locate", "locate", irisHandler.HandleLocate)
+	handlers.Route(mux, http.MethodGet, "/results", "results_list", resultsHandler.HandleListResults)
+	handlers.Route(mux, http.MethodGet, "/results/{id}", "results_get", resultsHandler.HandleGetResult)
+
+	serverConfig := config.GetServerConfig()
+	server := &http.Server{
+		Addr:           ":" + serverConfig.Port,
+		Handler:        mux,
+		ReadTimeout:    config.Timeout(serverConfig.ReadTimeout),
+		WriteTimeout:   config.Timeout(serverConfig.WriteTimeout),
+		IdleTimeout:    config.Timeout(serverConfig.IdleTimeout),
+		MaxHeaderBytes: serverConfig.MaxHeaderBytes,
+	}
+
+	// SIGTERM and SIGINT stop the loading and drain the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Serve health, readiness and metrics while the datasets load
+	go func() {
+		log.Printf("Server starting on port %s...", serverConfig.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}()
+
+	// Load the first dataset generation
+	if err := datasets.Load(ctx); err != nil {
+		if ctx.Err() == nil {
+			log.Fatalf("Error initializing CSV service: %v", err)
+		}
+		log.Printf("Shutdown requested while loading datasets: %v", err)
+		shutdown(server, serverConfig.ShutdownTimeout)
+		return
+	}
+	log.Printf("Datasets loaded, ready to serve requests")
+
+	// Reload the datasets when their files change
+	if reloadConfig := config.GetReloadConfig(); reloadConfig.WatchInterval > 0 {
+		log.Printf("Watching data files every %ds", reloadConfig.WatchInterval)
+		datasets.Watch(config.Timeout(reloadConfig.WatchInterval))
+	}
 
-	// Initialize services and handlers
-	csvService := services.NewCSVService()
-	searchHandler := handlers.NewSearchHandler(csvService)
-	irisHandler := handlers.NewIrisHandler(csvService)
+	// Serve until the process is stopped
+	<-ctx.Done()
+	stop()
+	datasets.Close()
+	shutdown(server, serverConfig.ShutdownTimeout)
+	// Requests still running after the drain timeout get ErrPoolClosed
+	workerPool.Close()
+}
 
-	// Set up routes
-	http.HandleFunc("/competitor-search", searchHandler.HandleSearch)
-	http.HandleFunc("/competitor-count", searchHandler.HandleCompetitorCount)
-	http.HandleFunc("/competition-data", searchHandler.HandleCompetitionData)
-	http.HandleFunc("/iris-data", irisHandler.HandleIrisData)
+// shutdown stops accepting connections and waits for in-flight requests, up to timeout seconds
+func shutdown(server *http.Server, timeout int) {
+	log.Printf("Shutting down, draining in-flight requests...")
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout(timeout))
+		defer cancel()
+	}
 
-	// Start server
-	port := "8080"
-	log.Printf("Server starting on port %s...", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Warning: requests still running after %ds, closing connections: %v", timeout, err)
+		server.Close()
+		return
 	}
+	log.Printf("Server stopped")
 }
\ No newline at end of file
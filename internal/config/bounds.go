@@ -0,0 +1,60 @@
+package config
+
+import "fmt"
+
+// BoundingBox is a range of WGS84 longitudes and latitudes, in degrees
+type BoundingBox struct {
+	Name   string  `json:"name"`
+	MinLng float64 `json:"min_lng"`
+	MinLat float64 `json:"min_lat"`
+	MaxLng float64 `json:"max_lng"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// Contains reports whether a position is inside the box
+func (b BoundingBox) Contains(lng, lat float64) bool {
+	return lng >= b.MinLng && lng <= b.MaxLng && lat >= b.MinLat && lat <= b.MaxLat
+}
+
+// Bounds lists the boxes that request positions must fall in. The `bounds` key of the
+// configuration file replaces the whole list.
+type Bounds []BoundingBox
+
+// Contains reports whether a position is inside any of the boxes
+func (b Bounds) Contains(lng, lat float64) bool {
+	for _, box := range b {
+		if box.Contains(lng, lat) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultBounds returns boxes covering metropolitan France, Corsica and the overseas
+// departments, with a small margin
+func defaultBounds() Bounds {
+	return Bounds{
+		{Name: "Metropolitan France", MinLng: -5.8, MinLat: 41.0, MaxLng: 10.0, MaxLat: 51.5},
+		{Name: "Guadeloupe", MinLng: -62.0, MinLat: 15.7, MaxLng: -60.8, MaxLat: 16.7},
+		{Name: "Martinique", MinLng: -61.4, MinLat: 14.2, MaxLng: -60.6, MaxLat: 15.0},
+		{Name: "French Guiana", MinLng: -54.8, MinLat: 1.9, MaxLng: -51.4, MaxLat: 6.0},
+		{Name: "Réunion", MinLng: 55.0, MinLat: -21.6, MaxLng: 56.0, MaxLat: -20.7},
+		{Name: "Mayotte", MinLng: 44.8, MinLat: -13.2, MaxLng: 45.5, MaxLat: -12.4},
+	}
+}
+
+// validateBounds rejects an empty list and boxes that are empty or outside of the WGS84 ranges
+func validateBounds(bounds Bounds) error {
+	if len(bounds) == 0 {
+		return fmt.Errorf("bounds must list at least one box")
+	}
+	for i, box := range bounds {
+		if box.MinLng < -180 || box.MaxLng > 180 || box.MinLat < -90 || box.MaxLat > 90 {
+			return fmt.Errorf("bounds box %d (%s) is outside of the WGS84 ranges", i, box.Name)
+		}
+		if box.MinLng >= box.MaxLng || box.MinLat >= box.MaxLat {
+			return fmt.Errorf("bounds box %d (%s) is empty", i, box.Name)
+		}
+	}
+	return nil
+}
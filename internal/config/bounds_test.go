@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultBoundsContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		lng, lat float64
+		want     bool
+	}{
+		{"Paris", 2.3522, 48.8566, true},
+		{"Ajaccio", 8.7386, 41.9192, true},
+		{"Pointe-à-Pitre", -61.5331, 16.2411, true},
+		{"Fort-de-France", -61.0588, 14.6161, true},
+		{"Cayenne", -52.3260, 4.9224, true},
+		{"Saint-Denis de La Réunion", 55.4504, -20.8823, true},
+		{"Mamoudzou", 45.2278, -12.7806, true},
+		{"Madrid", -3.7038, 40.4168, false},
+		{"New York", -74.0060, 40.7128, false},
+	}
+	bounds := defaultBounds()
+	for _, tt := range tests {
+		if got := bounds.Contains(tt.lng, tt.lat); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if err := validateBounds(bounds); err != nil {
+		t.Errorf("validateBounds(defaults) error = %v", err)
+	}
+}
+
+func TestReadConfigBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Bounds
+		wantErr string
+	}{
+		{
+			name: "override",
+			data: `{"bounds": [{"name": "Paris", "min_lng": 2.2, "min_lat": 48.8, "max_lng": 2.5, "max_lat": 48.95}]}`,
+			want: Bounds{{Name: "Paris", MinLng: 2.2, MinLat: 48.8, MaxLng: 2.5, MaxLat: 48.95}},
+		},
+		{name: "empty list", data: `{"bounds": []}`, want: defaultBounds(), wantErr: "bounds must list at least one box"},
+		{
+			name:    "empty box",
+			data:    `{"bounds": [{"name": "Paris", "min_lng": 2.5, "min_lat": 48.8, "max_lng": 2.2, "max_lat": 48.95}]}`,
+			want:    defaultBounds(),
+			wantErr: "bounds box 0 (Paris) is empty",
+		},
+		{
+			name:    "out of range",
+			data:    `{"bounds": [{"min_lng": -200, "min_lat": 48.8, "max_lng": 2.2, "max_lat": 48.95}]}`,
+			want:    defaultBounds(),
+			wantErr: "is outside of the WGS84 ranges",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile(ConfigFile, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("writing %s: %v", ConfigFile, err)
+			}
+
+			loaded, err := readConfig()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("readConfig() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("readConfig() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(loaded.bounds, tt.want) {
+				t.Errorf("bounds = %+v, want %+v", loaded.bounds, tt.want)
+			}
+		})
+	}
+}
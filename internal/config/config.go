@@ -2,8 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // DataDir is the base directory for all data files
@@ -21,6 +26,91 @@ type CSVConfig struct {
 
 var csvConfig CSVConfig
 
+// TimeoutConfig holds the processing deadline of each endpoint, in seconds.
+// A value of 0 disables the deadline.
+type TimeoutConfig struct {
+	CompetitorSearch int `json:"competitor_search"`
+	CompetitorCount  int `json:"competitor_count"`
+	CompetitionData  int `json:"competition_data"`
+	IrisData         int `json:"iris_data"`
+}
+
+var timeoutConfig TimeoutConfig
+
+// ServerConfig holds the HTTP server settings. Timeouts are in seconds and 0 disables them.
+// The write timeout must leave room for the longest endpoint deadline.
+type ServerConfig struct {
+	Port            string `json:"port"`
+	ReadTimeout     int    `json:"read_timeout"`
+	WriteTimeout    int    `json:"write_timeout"`
+	IdleTimeout     int    `json:"idle_timeout"`
+	ShutdownTimeout int    `json:"shutdown_timeout"`
+	MaxHeaderBytes  int    `json:"max_header_bytes"`
+}
+
+var serverConfig ServerConfig
+
+// ReloadConfig holds the settings of dataset reloads.
+// WatchInterval is the polling period of the data files in seconds, 0 disabling the watcher.
+// AdminToken is the bearer token required by the admin endpoints, which are disabled when it is empty.
+type ReloadConfig struct {
+	WatchInterval int    `json:"watch_interval"`
+	AdminToken    string `json:"admin_token"`
+}
+
+var reloadConfig ReloadConfig
+
+// ResultsConfig holds the settings of the result store.
+// Backend is one of disabled, filesystem or sqlite. Directory is used by the filesystem backend
+// and SQLitePath by the sqlite one. Results older than MaxAge seconds or beyond the MaxCount most
+// recent ones are deleted, 0 disabling the limit.
+type ResultsConfig struct {
+	Backend    string `json:"backend"`
+	Directory  string `json:"directory"`
+	SQLitePath string `json:"sqlite_path"`
+	MaxAge     int    `json:"max_age"`
+	MaxCount   int    `json:"max_count"`
+}
+
+var resultsConfig ResultsConfig
+
+// SimplificationConfig holds the thresholds of request polygon simplification.
+// Polygons with more than MaxPointsPerKm2 points per km² are simplified; the default of 0.08
+// is about 700 points per square degree at the latitude of France.
+type SimplificationConfig struct {
+	MaxPointsPerKm2 float64 `json:"max_points_per_km2"`
+}
+
+var simplificationConfig SimplificationConfig
+
+// bounds holds the areas request positions must fall in, see Bounds
+var bounds Bounds
+
+// schemaConfig holds the columns of the CSV files, see SchemaConfig
+var schemaConfig SchemaConfig
+
+// workerPoolSize is the number of workers computing zone intersections, 0 for GOMAXPROCS
+var workerPoolSize int
+
+// mu guards the settings above, which Reload replaces at runtime
+var mu sync.RWMutex
+
+// ConfigFile is the optional configuration file, read from the working directory
+const ConfigFile = "config.json"
+
+// settings is a complete configuration as read by readConfig
+type settings struct {
+	csv            CSVConfig
+	timeouts       TimeoutConfig
+	server         ServerConfig
+	reload         ReloadConfig
+	results        ResultsConfig
+	simplification SimplificationConfig
+	bounds         Bounds
+	schemas        SchemaConfig
+	workerPoolSize int
+}
+
 func init() {
 	// Set up data directory
 	if envDataDir := os.Getenv("DATA_DIR"); envDataDir != "" {
@@ -29,22 +119,164 @@ func init() {
 		DataDir = filepath.Join(".", "data")
 	}
 
-	// Default paths
-	csvConfig = CSVConfig{
-		BusinessData:     "StockEtablissement_open_only_and_geo_and_names.csv",
-		CompetitionData:  "chiffres-cles-2024.csv",
-		CommuneCrimes:    "crimes_per_commune.csv",
-		DepartmentCrimes: "dep-indexed-crime-data.csv",
-		IrisData:         "iris-data-with-polygon-coord-standard-with-area-and-calculations.csv",
-		CommuneData:      "full_commune_from_iris-05092024.csv",
-		QPData:           "final_special_zones-06092024.csv",
+	// Start with what could be read, the defaults filling the rest
+	loaded, err := readConfig()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	apply(loaded)
+}
+
+// Reload reads the configuration file and the environment again so that dataset paths,
+// columns and deadlines can change at runtime. The configuration is unchanged when the file is invalid.
+// The server, worker pool and result store settings are only used at startup.
+func Reload() error {
+	loaded, err := readConfig()
+	if err != nil {
+		return err
+	}
+	apply(loaded)
+	return nil
+}
+
+// apply makes loaded the current configuration
+func apply(loaded settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	csvConfig = loaded.csv
+	timeoutConfig = loaded.timeouts
+	serverConfig = loaded.server
+	reloadConfig = loaded.reload
+	resultsConfig = loaded.results
+	simplificationConfig = loaded.simplification
+	bounds = loaded.bounds
+	schemaConfig = loaded.schemas
+	workerPoolSize = loaded.workerPoolSize
+}
+
+// readConfig returns the defaults overridden by the configuration file and the environment.
+// On error it returns the settings read so far along with the error.
+func readConfig() (settings, error) {
+	loaded := settings{
+		// Default paths
+		csv: CSVConfig{
+			BusinessData:     "StockEtablissement_open_only_and_geo_and_names.csv",
+			CompetitionData:  "chiffres-cles-2024.csv",
+			CommuneCrimes:    "crimes_per_commune.csv",
+			DepartmentCrimes: "dep-indexed-crime-data.csv",
+			IrisData:         "iris-data-with-polygon-coord-standard-with-area-and-calculations.csv",
+			CommuneData:      "full_commune_from_iris-05092024.csv",
+			QPData:           "final_special_zones-06092024.csv",
+		},
+		// Default deadlines
+		timeouts: TimeoutConfig{
+			CompetitorSearch: 30,
+			CompetitorCount:  30,
+			CompetitionData:  120,
+			IrisData:         60,
+		},
+		// Default server settings
+		server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     30,
+			WriteTimeout:    150,
+			IdleTimeout:     120,
+			ShutdownTimeout: 130,
+			MaxHeaderBytes:  1 << 20,
+		},
+		// Default result store, keeping 30 days of results
+		results: ResultsConfig{
+			Backend:    "filesystem",
+			Directory:  "results",
+			SQLitePath: "results.db",
+			MaxAge:     30 * 24 * 3600,
+			MaxCount:   10000,
+		},
+		// Default simplification thresholds
+		simplification: SimplificationConfig{
+			MaxPointsPerKm2: 0.08,
+		},
+		bounds:  defaultBounds(),
+		schemas: defaultSchemas(),
 	}
 
 	// Try to load config from file
-	if configFile, err := os.Open("config.json"); err == nil {
-		defer configFile.Close()
-		json.NewDecoder(configFile).Decode(&csvConfig)
+	var err error
+	if configData, readErr := os.ReadFile(ConfigFile); readErr == nil {
+		if jsonErr := json.Unmarshal(configData, &loaded.csv); jsonErr != nil {
+			err = fmt.Errorf("invalid %s: %v", ConfigFile, jsonErr)
+		} else {
+			// Other settings are set next to the file names
+			fileConfig := struct {
+				Timeouts       *TimeoutConfig        `json:"timeouts"`
+				WorkerPoolSize *int                  `json:"worker_pool_size"`
+				Server         *ServerConfig         `json:"server"`
+				Reload         *ReloadConfig         `json:"reload"`
+				Results        *ResultsConfig        `json:"results"`
+				Simplification *SimplificationConfig `json:"simplification"`
+				Bounds         *Bounds               `json:"bounds"`
+				Schemas        json.RawMessage       `json:"schemas"`
+			}{Timeouts: &loaded.timeouts, WorkerPoolSize: &loaded.workerPoolSize, Server: &loaded.server, Reload: &loaded.reload, Results: &loaded.results, Simplification: &loaded.simplification, Bounds: &loaded.bounds}
+			if jsonErr := json.Unmarshal(configData, &fileConfig); jsonErr != nil {
+				err = fmt.Errorf("invalid %s: %v", ConfigFile, jsonErr)
+			} else {
+				if boundsErr := validateBounds(loaded.bounds); boundsErr != nil {
+					// Keep the default areas rather than rejecting every position
+					loaded.bounds = defaultBounds()
+					err = fmt.Errorf("invalid bounds in %s: %v", ConfigFile, boundsErr)
+				}
+				if fileConfig.Schemas != nil {
+					// Schemas list the columns that differ from the default ones
+					if schemaErr := mergeSchemas(loaded.schemas, fileConfig.Schemas); schemaErr != nil {
+						err = fmt.Errorf("invalid schemas in %s: %v", ConfigFile, schemaErr)
+					}
+				}
+			}
+		}
+	} else if !os.IsNotExist(readErr) {
+		err = fmt.Errorf("error reading %s: %v", ConfigFile, readErr)
 	}
+
+	// The environment overrides the server, reload and results settings of the file
+	if envPort := os.Getenv("PORT"); envPort != "" {
+		loaded.server.Port = envPort
+	}
+	envInt("SERVER_READ_TIMEOUT", &loaded.server.ReadTimeout)
+	envInt("SERVER_WRITE_TIMEOUT", &loaded.server.WriteTimeout)
+	envInt("SERVER_IDLE_TIMEOUT", &loaded.server.IdleTimeout)
+	envInt("SERVER_SHUTDOWN_TIMEOUT", &loaded.server.ShutdownTimeout)
+	envInt("SERVER_MAX_HEADER_BYTES", &loaded.server.MaxHeaderBytes)
+	envInt("RELOAD_WATCH_INTERVAL", &loaded.reload.WatchInterval)
+	if envToken := os.Getenv("ADMIN_TOKEN"); envToken != "" {
+		loaded.reload.AdminToken = envToken
+	}
+	if envBackend := os.Getenv("RESULTS_BACKEND"); envBackend != "" {
+		loaded.results.Backend = envBackend
+	}
+	if envDir := os.Getenv("RESULTS_DIR"); envDir != "" {
+		loaded.results.Directory = envDir
+	}
+	if envPath := os.Getenv("RESULTS_SQLITE_PATH"); envPath != "" {
+		loaded.results.SQLitePath = envPath
+	}
+	envInt("RESULTS_MAX_AGE", &loaded.results.MaxAge)
+	envInt("RESULTS_MAX_COUNT", &loaded.results.MaxCount)
+
+	return loaded, err
+}
+
+// envInt sets value from an integer environment variable, keeping it when the variable is unset or invalid
+func envInt(name string, value *int) {
+	envValue := os.Getenv(name)
+	if envValue == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(envValue)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: ignoring invalid %s value %q", name, envValue)
+		return
+	}
+	*value = parsed
 }
 
 // GetDataFilePath returns the absolute path for a data file
@@ -54,5 +286,68 @@ func GetDataFilePath(filename string) string {
 
 // GetCSVConfig returns the CSV configuration
 func GetCSVConfig() CSVConfig {
+	mu.RLock()
+	defer mu.RUnlock()
 	return csvConfig
-}
\ No newline at end of file
+}
+
+// GetTimeoutConfig returns the endpoint deadlines configuration
+func GetTimeoutConfig() TimeoutConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return timeoutConfig
+}
+
+// GetServerConfig returns the HTTP server configuration
+func GetServerConfig() ServerConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return serverConfig
+}
+
+// GetReloadConfig returns the dataset reload configuration
+func GetReloadConfig() ReloadConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return reloadConfig
+}
+
+// GetResultsConfig returns the result store configuration
+func GetResultsConfig() ResultsConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return resultsConfig
+}
+
+// GetSimplificationConfig returns the polygon simplification thresholds
+func GetSimplificationConfig() SimplificationConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return simplificationConfig
+}
+
+// GetBounds returns the areas request positions must fall in. The list must not be modified.
+func GetBounds() Bounds {
+	mu.RLock()
+	defer mu.RUnlock()
+	return bounds
+}
+
+// GetSchemaConfig returns the columns of the CSV files. The schemas must not be modified.
+func GetSchemaConfig() SchemaConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return schemaConfig
+}
+
+// GetWorkerPoolSize returns the configured number of intersection workers, 0 meaning GOMAXPROCS
+func GetWorkerPoolSize() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return workerPoolSize
+}
+
+// Timeout converts a deadline in seconds to a duration
+func Timeout(seconds int) time.Duration {
+	return time.Duration(seconds) * time.Second
+}
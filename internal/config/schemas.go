@@ -0,0 +1,277 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Column is a column read by a CSV loader: the header it is found under, and whether the
+// dataset loads without it. Headers are matched case-insensitively.
+type Column struct {
+	Header   string `json:"header"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Schema maps the fields read from a CSV file to its columns
+type Schema map[string]Column
+
+// SchemaConfig holds the schema of every dataset, keyed like the file names of CSVConfig.
+// The fields of the IRIS schema other than its identifiers, polygon and area are the IRIS
+// statistics, so a statistic can be added without changing the code.
+type SchemaConfig map[string]Schema
+
+// required returns a schema made of required columns, from pairs of field and header
+func required(pairs ...string) Schema {
+	schema := make(Schema, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		schema[pairs[i]] = Column{Header: pairs[i+1]}
+	}
+	return schema
+}
+
+// optional adds optional columns to a schema, from pairs of field and header
+func (s Schema) optional(pairs ...string) Schema {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		s[pairs[i]] = Column{Header: pairs[i+1], Optional: true}
+	}
+	return s
+}
+
+// defaultSchemas returns the columns of the default data files: SIRENE establishments,
+// Infogreffe key figures and INSEE IRIS statistics
+func defaultSchemas() SchemaConfig {
+	return SchemaConfig{
+		"business_data": required(
+			"siret", "siret",
+			"name", "denominationUniteLegale",
+			"naf_code", "activitePrincipaleEtablissement",
+			"longitude", "longitude",
+			"latitude", "latitude",
+		).optional(
+			"address_complement", "complementAdresseEtablissement",
+			"street_number", "numeroVoieEtablissement",
+			"street_type", "typeVoieEtablissement",
+			"street_name", "libelleVoieEtablissement",
+			"postal_code", "codePostalEtablissement",
+			"city", "libelleCommuneEtablissement",
+		),
+		"competition_data": required(
+			"siren", "siren",
+			"nic", "nic",
+			"status", "statut",
+			"publication_date", "date_de_publication",
+			"ca_1", "ca_1",
+			"result_1", "resultat_1",
+			"employees_1", "effectif_1",
+			"ca_2", "ca_2",
+			"result_2", "resultat_2",
+			"employees_2", "effectif_2",
+			"ca_3", "ca_3",
+			"result_3", "resultat_3",
+			"employees_3", "effectif_3",
+		).optional(
+			"name", "denomination",
+			"legal_status", "forme_juridique",
+			"code_ape", "code_ape",
+			"label_ape", "libelle_ape",
+			"address", "adresse",
+			"postal_code", "code_postal",
+			"city", "ville",
+			"num_department", "num_dept",
+			"department", "departement",
+			"region", "region",
+			"code_greffe", "code_greffe",
+			"greffe", "greffe",
+			"registration_date", "date_immatriculation",
+			"deregistration_date", "date_radiation",
+			"geolocation", "geolocalisation",
+			"millesime_1", "millesime_1",
+			"date_close_1", "date_de_cloture_exercice_1",
+			"duration_1", "duree_1",
+			"millesime_2", "millesime_2",
+			"date_close_2", "date_de_cloture_exercice_2",
+			"duration_2", "duree_2",
+			"millesime_3", "millesime_3",
+			"date_close_3", "date_de_cloture_exercice_3",
+			"duration_3", "duree_3",
+			"range_ca_1", "tranche_ca_millesime_1",
+			"range_ca_2", "tranche_ca_millesime_2",
+			"range_ca_3", "tranche_ca_millesime_3",
+		),
+		// Every other column of the crime files is a crime type
+		"commune_crimes": required(
+			"commune_code", "CODGEO_2023",
+		),
+		"department_crimes": required(
+			"department_code", "Code.département",
+			"population", "POP",
+		),
+		"iris_data": required(
+			"iris", "IRIS",
+			"commune", "COM",
+			"type", "TYP_IRIS",
+			"label", "LAB_IRIS",
+			"polygon", "POLYGON",
+			"area", "AREA",
+			"population_total", "P20_POP",
+			"population_general_age_0002", "P20_POP0002",
+			"population_general_age_0305", "P20_POP0305",
+			"population_general_age_0610", "P20_POP0610",
+			"population_general_age_1117", "P20_POP1117",
+			"population_general_age_1824", "P20_POP1824",
+			"population_general_age_2539", "P20_POP2539",
+			"population_general_age_4054", "P20_POP4054",
+			"population_general_age_5564", "P20_POP5564",
+			"population_general_age_6579", "P20_POP6579",
+			"population_general_age_80P", "P20_POP80P",
+			"population_total_age_0014", "P20_POP0014",
+			"population_total_age_1529", "P20_POP1529",
+			"population_total_age_3044", "P20_POP3044",
+			"population_total_age_4559", "P20_POP4559",
+			"population_total_age_6074", "P20_POP6074",
+			"population_total_age_75P", "P20_POP75P",
+			"population_total_age_0019", "P20_POP0019",
+			"population_total_age_2064", "P20_POP2064",
+			"population_total_age_65P", "P20_POP65P",
+			"population_male", "P20_POPH",
+			"population_male_age_0014", "P20_H0014",
+			"population_male_age_1529", "P20_H1529",
+			"population_male_age_3044", "P20_H3044",
+			"population_male_age_4559", "P20_H4559",
+			"population_male_age_6074", "P20_H6074",
+			"population_male_age_75P", "P20_H75P",
+			"population_male_age_0019", "P20_H0019",
+			"population_male_age_2064", "P20_H2064",
+			"population_male_age_65P", "P20_H65P",
+			"population_female", "P20_POPF",
+			"population_female_age_0014", "P20_F0014",
+			"population_female_age_1529", "P20_F1529",
+			"population_female_age_3044", "P20_F3044",
+			"population_female_age_4559", "P20_F4559",
+			"population_female_age_6074", "P20_F6074",
+			"population_female_age_75P", "P20_F75P",
+			"population_female_age_0019", "P20_F0019",
+			"population_female_age_2064", "P20_F2064",
+			"population_female_age_65P", "P20_F65P",
+			"employees_category_1", "C20_POP15P_CS1",
+			"employees_category_2", "C20_POP15P_CS2",
+			"employees_category_3", "C20_POP15P_CS3",
+			"employees_category_4", "C20_POP15P_CS4",
+			"employees_category_5", "C20_POP15P_CS5",
+			"employees_category_6", "C20_POP15P_CS6",
+			"employees_category_7", "C20_POP15P_CS7",
+			"employees_category_8", "C20_POP15P_CS8",
+			"employees_male", "C20_H15P",
+			"employees_male_category_1", "C20_H15P_CS1",
+			"employees_male_category_2", "C20_H15P_CS2",
+			"employees_male_category_3", "C20_H15P_CS3",
+			"employees_male_category_4", "C20_H15P_CS4",
+			"employees_male_category_5", "C20_H15P_CS5",
+			"employees_male_category_6", "C20_H15P_CS6",
+			"employees_male_category_7", "C20_H15P_CS7",
+			"employees_male_category_8", "C20_H15P_CS8",
+			"employees_female", "C20_F15P",
+			"employees_female_category_1", "C20_F15P_CS1",
+			"employees_female_category_2", "C20_F15P_CS2",
+			"employees_female_category_3", "C20_F15P_CS3",
+			"employees_female_category_4", "C20_F15P_CS4",
+			"employees_female_category_5", "C20_F15P_CS5",
+			"employees_female_category_6", "C20_F15P_CS6",
+			"employees_female_category_7", "C20_F15P_CS7",
+			"employees_female_category_8", "C20_F15P_CS8",
+			"population_french", "P20_POP_FR",
+			"population_foreign", "P20_POP_ETR",
+			"population_immigrant", "P20_POP_IMM",
+			"housing_people_per_home", "P20_PMEN",
+			"housing_people_in_collective_housing", "P20_PHORMEN",
+			"families_only_number", "C20_FAM",
+			"families_with_kids", "C20_COUPAENF",
+			"families_monoparental", "C20_FAMMONO",
+			"families_without_kids", "C20_COUPSENF",
+			"families_with_1_kids_under_25", "C20_NE24F1",
+			"families_with_2_kids_under_25", "C20_NE24F2",
+			"families_with_3_kids_under_25", "C20_NE24F3",
+			"families_with_4p_kids_under_25", "C20_NE24F4P",
+			"families_number", "C20_MEN",
+			"families_one_person", "C20_MENPSEUL",
+			"families_living_without_family", "C20_MENSFAM",
+			"families_living_with_family", "C20_MENFAM",
+			"employees_number", "P20_ACT1564",
+			"students_number", "P20_ETUD1564",
+			"housing_total", "P20_LOG",
+			"housing_primary_residence", "P20_RP",
+			"housing_secondary_residence", "P20_RSECOCC",
+			"housing_empty_residence", "P20_LOGVAC",
+			"housing_houses", "P20_MAISON",
+			"housing_apartments", "P20_APPART",
+			"housing_rooms_1_rooms", "P20_RP_1P",
+			"housing_rooms_2_rooms", "P20_RP_2P",
+			"housing_rooms_3_rooms", "P20_RP_3P",
+			"housing_rooms_4_rooms", "P20_RP_4P",
+			"housing_rooms_5p_rooms", "P20_RP_5PP",
+			"housing_houses_constructed_before_19", "P20_RP_ACH19",
+			"housing_houses_constructed_19_45", "P20_RP_ACH45",
+			"housing_houses_constructed_46_70", "P20_RP_ACH70",
+			"housing_houses_constructed_71_90", "P20_RP_ACH90",
+			"housing_houses_constructed_91_05", "P20_RP_ACH05",
+			"housing_houses_constructed_06_17", "P20_RP_ACH17",
+			"housing_moved_since_0_2_years", "P20_MEN_ANEM0002",
+			"housing_moved_since_2_4_years", "P20_MEN_ANEM0204",
+			"housing_moved_since_5_9_years", "P20_MEN_ANEM0509",
+			"housing_moved_since_10p_years", "P20_MEN_ANEM10P",
+			"housing_owners", "P20_RP_PROP",
+			"housing_renters", "P20_RP_LOC",
+			"housing_with_parkings", "P20_RP_GARL",
+			"housing_with_atleast_1_cars", "P20_RP_VOIT1P",
+			"housing_with_1_cars", "P20_RP_VOIT1",
+			"housing_with_2p_cars", "P20_RP_VOIT2P",
+		),
+		"commune_data": required(
+			"commune_code", "COM",
+			"population", "P20_POP",
+			"polygon", "POLYGON",
+			"postal_code", "CODE_POSTAL",
+			"name", "LIBCOM",
+			"surface_area", "AREA",
+		).optional(
+			"median_income", "MED20",
+		),
+		"qp_data": required(
+			"id", "id",
+			"code_qp", "Code_QP",
+			"label", "Lib_QP",
+			"commune", "Commune",
+			"polygon", "polygon",
+		),
+	}
+}
+
+// mergeSchemas replaces the default columns by those of the configuration file, which
+// only lists the columns that differ. It rejects unknown datasets and columns without a header.
+func mergeSchemas(schemas SchemaConfig, data json.RawMessage) error {
+	var overrides SchemaConfig
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return err
+	}
+
+	datasets := make([]string, 0, len(overrides))
+	for dataset := range overrides {
+		datasets = append(datasets, dataset)
+	}
+	sort.Strings(datasets)
+	for _, dataset := range datasets {
+		schema, exists := schemas[dataset]
+		if !exists {
+			return fmt.Errorf("unknown dataset %q in schemas", dataset)
+		}
+		for field, column := range overrides[dataset] {
+			if strings.TrimSpace(column.Header) == "" {
+				return fmt.Errorf("column %s of %s has no header", field, dataset)
+			}
+			schema[field] = column
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "override", data: `{"commune_data": {"median_income": {"header": "MED21"}, "name": {"header": "NOM"}}}`},
+		{name: "unknown dataset", data: `{"communes": {"name": {"header": "NOM"}}}`, wantErr: `unknown dataset "communes" in schemas`},
+		{name: "empty header", data: `{"qp_data": {"label": {"header": " "}}}`, wantErr: "column label of qp_data has no header"},
+		{name: "missing header", data: `{"qp_data": {"label": {"optional": true}}}`, wantErr: "column label of qp_data has no header"},
+		{name: "invalid JSON", data: `{"qp_data": []}`, wantErr: "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemas := defaultSchemas()
+			err := mergeSchemas(schemas, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("mergeSchemas() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeSchemas() error = %v", err)
+			}
+
+			communes := schemas["commune_data"]
+			if column := communes["median_income"]; column.Header != "MED21" || column.Optional {
+				t.Errorf("median_income = %+v, want the configured column", column)
+			}
+			if column := communes["name"]; column.Header != "NOM" || column.Optional {
+				t.Errorf("name = %+v, want the configured column", column)
+			}
+			if column := communes["commune_code"]; column.Header != "COM" {
+				t.Errorf("commune_code = %+v, want the default column", column)
+			}
+		})
+	}
+}
+
+func TestDefaultSchemaHeaders(t *testing.T) {
+	for dataset, schema := range defaultSchemas() {
+		fields := make(map[string]string)
+		for field, column := range schema {
+			header := strings.ToLower(column.Header)
+			if strings.TrimSpace(header) == "" {
+				t.Errorf("%s: %s has no header", dataset, field)
+			}
+			if other, exists := fields[header]; exists {
+				t.Errorf("%s: %s and %s both use header %q", dataset, field, other, column.Header)
+			}
+			fields[header] = field
+		}
+	}
+}
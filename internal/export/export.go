@@ -0,0 +1,109 @@
+// Package export writes tables of results as CSV or XLSX spreadsheets.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Table is a sheet of results. Values are strings, numbers or nil for empty cells.
+// Text starting like a formula is written with a leading quote.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// CSVOptions sets the conventions of a CSV file
+type CSVOptions struct {
+	// French uses semicolons between fields and commas as decimal separators, and starts
+	// the file with a byte order mark so that Excel detects UTF-8
+	French bool
+}
+
+// WriteCSV writes the table as CSV with a header row
+func WriteCSV(w io.Writer, table Table, options CSVOptions) error {
+	writer := csv.NewWriter(w)
+	if options.French {
+		writer.Comma = ';'
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		record[i] = formatText(column)
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	for _, row := range table.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = formatValue(row[i], options.French)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatValue formats a cell value as CSV text
+func formatValue(value interface{}, french bool) string {
+	number, isNumber := toNumber(value)
+	if !isNumber {
+		if value == nil {
+			return ""
+		}
+		return formatText(fmt.Sprint(value))
+	}
+
+	text := strconv.FormatFloat(number, 'f', -1, 64)
+	if french {
+		text = strings.Replace(text, ".", ",", 1)
+	}
+	return text
+}
+
+// formulaPrefixes are the first characters that make spreadsheets read a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// formatText formats a text cell, prefixing text that spreadsheets would read as a formula
+// with a quote so that a value taken from a request or a data file is never evaluated
+func formatText(text string) string {
+	if text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// toNumber returns the value of numeric cells. NaN and infinite values are not numbers in spreadsheets.
+func toNumber(value interface{}) (float64, bool) {
+	var number float64
+	switch v := value.(type) {
+	case float64:
+		number = v
+	case float32:
+		number = float64(v)
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	default:
+		return 0, false
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+	return number, true
+}
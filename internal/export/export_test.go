@@ -0,0 +1,176 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testTable has a text, a decimal, an empty and a formula-like cell
+var testTable = Table{
+	Name:    "iris/data",
+	Columns: []string{"key", "label", "value", "=feature"},
+	Rows: [][]interface{}{
+		{"population_total", "Population", 1234.5, nil},
+		{"share_owners", "Part des propriétaires", 0.25, "=HYPERLINK(\"http://example.com\")"},
+		{"delta", "-2+3", -1.5, math.NaN()},
+	},
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CSVOptions
+		want    string
+	}{
+		{
+			name: "default",
+			want: "key,label,value,'=feature\n" +
+				"population_total,Population,1234.5,\n" +
+				"share_owners,Part des propriétaires,0.25,\"'=HYPERLINK(\"\"http://example.com\"\")\"\n" +
+				"delta,'-2+3,-1.5,NaN\n",
+		},
+		{
+			name:    "french",
+			options: CSVOptions{French: true},
+			want: "\ufeffkey;label;value;'=feature\n" +
+				"population_total;Population;1234,5;\n" +
+				"share_owners;Part des propriétaires;0,25;\"'=HYPERLINK(\"\"http://example.com\"\")\"\n" +
+				"delta;'-2+3;-1,5;NaN\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := WriteCSV(&output, testTable, tt.options); err != nil {
+				t.Fatalf("WriteCSV() error = %v", err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("WriteCSV() =\n%q\nwant\n%q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"Paris":            "Paris",
+		"=1+1":             "'=1+1",
+		"+33 1 23 45 67":   "'+33 1 23 45 67",
+		"-cmd":             "'-cmd",
+		"@SUM(A1:A2)":      "'@SUM(A1:A2)",
+		"\t=1+1":           "'\t=1+1",
+		"a=1":              "a=1",
+		"feature_75056_01": "feature_75056_01",
+	}
+	for text, want := range tests {
+		if got := formatText(text); got != want {
+			t.Errorf("formatText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+// xlsxCell is a cell of a worksheet part
+type xlsxCell struct {
+	Ref   string `xml:"r,attr"`
+	Type  string `xml:"t,attr"`
+	Value string `xml:"v"`
+	Text  string `xml:"is>t"`
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteXLSX(&output, testTable); err != nil {
+		t.Fatalf("WriteXLSX() error = %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil {
+		t.Fatalf("opening workbook: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", file.Name, err)
+		}
+		parts[file.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		content, exists := parts[name]
+		if !exists {
+			t.Errorf("workbook has no %s part", name)
+			continue
+		}
+		if err := xml.Unmarshal([]byte(content), new(struct{})); err != nil {
+			t.Errorf("%s is not valid XML: %v", name, err)
+		}
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/workbook.xml"]), &workbook); err != nil {
+		t.Fatalf("decoding workbook: %v", err)
+	}
+	if len(workbook.Sheets) != 1 || workbook.Sheets[0].Name != "iris_data" {
+		t.Errorf("sheets = %+v, want a single iris_data sheet", workbook.Sheets)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Ref   string     `xml:"r,attr"`
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("decoding worksheet: %v", err)
+	}
+	cells := make(map[string]xlsxCell)
+	for _, row := range sheet.Rows {
+		for _, cell := range row.Cells {
+			cells[cell.Ref] = cell
+		}
+	}
+	if len(sheet.Rows) != 4 {
+		t.Errorf("worksheet has %d rows, want 4", len(sheet.Rows))
+	}
+
+	texts := map[string]string{
+		"A1": "key",
+		"D1": "'=feature",
+		"B3": "Part des propriétaires",
+		"D3": "'=HYPERLINK(\"http://example.com\")",
+		"B4": "'-2+3",
+		"D4": "NaN",
+	}
+	for ref, want := range texts {
+		if cell := cells[ref]; cell.Type != "inlineStr" || cell.Text != want {
+			t.Errorf("cell %s = %+v, want text %q", ref, cell, want)
+		}
+	}
+	numbers := map[string]string{"C2": "1234.5", "C3": "0.25", "C4": "-1.5"}
+	for ref, want := range numbers {
+		if cell := cells[ref]; cell.Type != "" || cell.Value != want {
+			t.Errorf("cell %s = %+v, want number %s", ref, cell, want)
+		}
+	}
+	if _, exists := cells["D2"]; exists {
+		t.Error("empty cell D2 is written")
+	}
+	if !strings.Contains(parts["[Content_Types].xml"], "/xl/worksheets/sheet1.xml") {
+		t.Error("content types do not declare the worksheet")
+	}
+}
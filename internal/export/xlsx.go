@@ -0,0 +1,143 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// XLSXContentType is the media type of XLSX files
+const XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetNameLength is the longest sheet name Excel accepts
+const maxSheetNameLength = 31
+
+// xlsxStaticParts are the package parts that do not depend on the table
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// WriteXLSX writes the table as a single sheet workbook with a header row.
+// Strings are written inline so the workbook needs no shared strings part.
+func WriteXLSX(w io.Writer, table Table) error {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		writer, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, part.content); err != nil {
+			return err
+		}
+	}
+
+	writer, err := archive.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(writer, `%s<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		xml.Header, escapeXML(sheetName(table.Name))); err != nil {
+		return err
+	}
+
+	writer, err = archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(writer, table); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// writeSheet writes the worksheet part of the table
+func writeSheet(w io.Writer, table Table) error {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString(xml.Header)
+	buffered.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+	writeRow(buffered, 1, header)
+	for i, row := range table.Rows {
+		writeRow(buffered, i+2, row)
+	}
+
+	buffered.WriteString(`</sheetData></worksheet>`)
+	return buffered.Flush()
+}
+
+// writeRow writes the cells of one row, rowNumber starting at 1
+func writeRow(w *bufio.Writer, rowNumber int, row []interface{}) {
+	fmt.Fprintf(w, `<row r="%d">`, rowNumber)
+	for i, value := range row {
+		if value == nil {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(rowNumber)
+		if number, isNumber := toNumber(value); isNumber {
+			fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(number, 'g', -1, 64))
+			continue
+		}
+		fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(formatText(fmt.Sprint(value))))
+	}
+	w.WriteString(`</row>`)
+}
+
+// columnName returns the letters of a column index starting at 0: A, B, ..., Z, AA, ...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// sheetName makes a name Excel accepts: no []:*?/\ characters and at most 31 characters
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	return name
+}
+
+// escapeXML escapes text for XML content and attributes, replacing characters XML cannot hold
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,22 @@
+// Package geomtest builds the geometries used by tests and benchmarks.
+package geomtest
+
+import (
+	"math"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// circleVertices is the number of vertices of the polygons returned by Circle
+const circleVertices = 64
+
+// Circle returns a 64-vertex circle of the given radius in degrees around Paris
+func Circle(radius float64) geom2.Geometry {
+	coords := make([]float64, 0, (circleVertices+1)*2)
+	for i := 0; i <= circleVertices; i++ {
+		angle := 2 * math.Pi * float64(i%circleVertices) / circleVertices
+		coords = append(coords, 2.35+radius*math.Cos(angle), 48.85+radius*math.Sin(angle))
+	}
+	ring := geom2.NewLineString(geom2.NewSequence(coords, geom2.DimXY))
+	return geom2.NewPolygon([]geom2.LineString{ring}).AsGeometry()
+}
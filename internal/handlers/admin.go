@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/services"
+)
+
+// AdminHandler serves the administration endpoints
+type AdminHandler struct {
+	datasets *services.Datasets
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(datasets *services.Datasets) *AdminHandler {
+	return &AdminHandler{
+		datasets: datasets,
+	}
+}
+
+// authorized checks the "Authorization: Bearer <token>" header of the request, writing a 401
+// response when it is missing or does not match. The admin endpoints are disabled with a 403 response until an admin token is configured.
+func authorized(w http.ResponseWriter, r *http.Request) bool {
+	token := config.GetReloadConfig().AdminToken
+	if token == "" {
+		writeError(w, http.StatusForbidden, &apiError{Code: "admin_disabled", Message: "Admin endpoints are disabled, no admin token is configured"})
+		return false
+	}
+
+	provided, isBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !isBearer || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, &apiError{Code: "unauthorized", Message: "Invalid or missing admin token"})
+		return false
+	}
+	return true
+}
+
+// HandleReload starts loading a new dataset generation in the background.
+// Requests keep being served by the current generation until the new one is swapped in.
+func (h *AdminHandler) HandleReload(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r) {
+		return
+	}
+
+	if err := h.datasets.Reload(services.ReloadTriggerAdmin); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, h.datasets.Status())
+}
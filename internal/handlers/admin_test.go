@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"csv-processor/internal/config"
+)
+
+func TestHandleReloadAuthorization(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		status        int
+	}{
+		{"no token configured", "", "Bearer anything", http.StatusForbidden},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"token without scheme", "secret", "secret", http.StatusUnauthorized},
+		{"other scheme", "secret", "Basic secret", http.StatusUnauthorized},
+		{"lowercase scheme", "secret", "bearer secret", http.StatusUnauthorized},
+	}
+	// The subtests restore the environment when they end, so this reads the original configuration again
+	t.Cleanup(func() {
+		if err := config.Reload(); err != nil {
+			t.Errorf("restoring configuration: %v", err)
+		}
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tt.token)
+			if err := config.Reload(); err != nil {
+				t.Fatalf("reloading configuration: %v", err)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			// Unauthorized requests are rejected before the datasets are used
+			NewAdminHandler(nil).HandleReload(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"csv-processor/internal/services"
+)
+
+// apiError is the JSON body of every error response.
+// Details point to the faulty part of the request when there is one.
+type apiError struct {
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Error implements the error interface
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// withDetail adds a detail to the error and returns it
+func (e *apiError) withDetail(key string, value interface{}) *apiError {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// writeError writes an error as a JSON response with the given status
+func writeError(w http.ResponseWriter, status int, err *apiError) {
+	writeJSON(w, status, err)
+}
+
+// writeRequestError writes an invalid request error as a JSON 400 response
+func writeRequestError(w http.ResponseWriter, err *apiError) {
+	writeError(w, http.StatusBadRequest, err)
+}
+
+// writeMethodNotAllowed writes a JSON 405 response
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, &apiError{Code: "method_not_allowed", Message: "Method not allowed"})
+}
+
+// statusClientClosedRequest is the nginx status for requests canceled by the client. It is recorded
+// for canceled requests so that metrics do not count them as successes.
+const statusClientClosedRequest = 499
+
+// serviceErrorStatus maps the kind of a service error to an HTTP status
+func serviceErrorStatus(kind services.ErrorKind) int {
+	switch kind {
+	case services.ErrorNotFound:
+		return http.StatusNotFound
+	case services.ErrorInvalidGeometry, services.ErrorInvalidInput:
+		return http.StatusBadRequest
+	case services.ErrorConflict:
+		return http.StatusConflict
+	case services.ErrorDataUnavailable:
+		return http.StatusServiceUnavailable
+	case services.ErrorTimeout:
+		return http.StatusGatewayTimeout
+	case services.ErrorCanceled:
+		return statusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeServiceError writes an error returned by the services as a JSON response.
+// Errors that are not service errors are reported as internal errors without their message.
+func writeServiceError(w http.ResponseWriter, err error) {
+	var serviceErr *services.ServiceError
+	if !errors.As(err, &serviceErr) {
+		log.Printf("Error processing request: %v", err)
+		writeError(w, http.StatusInternalServerError, &apiError{
+			Code:    string(services.ErrorInternal),
+			Message: "Error processing request",
+		})
+		return
+	}
+
+	// Nobody is left to read the body of a canceled request
+	if serviceErr.Kind == services.ErrorCanceled {
+		log.Printf("Request canceled: %v", err)
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+
+	status := serviceErrorStatus(serviceErr.Kind)
+	if status >= http.StatusInternalServerError {
+		log.Printf("Error processing request: %v", err)
+	}
+	writeError(w, status, &apiError{
+		Code:    string(serviceErr.Kind),
+		Message: serviceErr.Message,
+		Details: serviceErr.Details,
+	})
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"csv-processor/internal/export"
+	"csv-processor/internal/models"
+)
+
+// areaResult is the result computed for one area of a request
+type areaResult struct {
+	feature interface{} // index of the request feature, nil for the single geometry and the union
+	id      interface{} // ID of the request feature
+	result  interface{}
+}
+
+// listedResults returns the results whose items are listed as rows: the single result, the
+// union of a combined FeatureCollection, or every feature result. withFeature tells whether
+// rows need the feature columns.
+func listedResults(response interface{}) (results []areaResult, withFeature bool) {
+	collection, isCollection := response.(*models.FeatureCollectionResponse)
+	if !isCollection {
+		return []areaResult{{result: response}}, false
+	}
+	if collection.Combined != nil {
+		return []areaResult{{result: collection.Combined}}, false
+	}
+	return featureResults(collection), true
+}
+
+// featureResults returns the result of every feature of a FeatureCollection response
+func featureResults(collection *models.FeatureCollectionResponse) []areaResult {
+	results := make([]areaResult, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		results = append(results, areaResult{feature: i, id: feature.ID, result: feature.Result})
+	}
+	return results
+}
+
+// featureColumns are the first columns of rows listing the items of several features
+var featureColumns = []string{"feature", "feature_id"}
+
+// featureCells returns the feature columns of a row
+func featureCells(result areaResult) []interface{} {
+	var id interface{}
+	if result.id != nil {
+		id = fmt.Sprint(result.id)
+	}
+	return []interface{}{result.feature, id}
+}
+
+// competitorsTable lists the businesses of a competitor search response, one per row
+func competitorsTable(response interface{}) export.Table {
+	results, withFeature := listedResults(response)
+	table := export.Table{
+		Name:    "competitors",
+		Columns: []string{"siret", "name", "naf_code", "address", "latitude", "longitude"},
+	}
+	if withFeature {
+		table.Columns = append(append([]string{}, featureColumns...), table.Columns...)
+	}
+
+	for _, result := range results {
+		businesses, _ := result.result.([]*models.Business)
+		for _, business := range businesses {
+			row := []interface{}{business.Siret, business.Name, business.NAFCode, business.Address, business.Latitude, business.Longitude}
+			if withFeature {
+				row = append(featureCells(result), row...)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	return table
+}
+
+// competitorDataColumns are the columns of the competition data of a competitor
+var competitorDataColumns = []struct {
+	name  string
+	value func(data *models.BusinessData) interface{}
+}{
+	{"legal_status", func(d *models.BusinessData) interface{} { return d.LegalStatus }},
+	{"code_ape", func(d *models.BusinessData) interface{} { return d.CodeAPE }},
+	{"label_ape", func(d *models.BusinessData) interface{} { return d.LabelAPE }},
+	{"registered_address", func(d *models.BusinessData) interface{} { return d.Address }},
+	{"postal_code", func(d *models.BusinessData) interface{} { return d.PostalCode }},
+	{"city", func(d *models.BusinessData) interface{} { return d.City }},
+	{"department_number", func(d *models.BusinessData) interface{} { return d.NumDepartment }},
+	{"department", func(d *models.BusinessData) interface{} { return d.Department }},
+	{"region", func(d *models.BusinessData) interface{} { return d.Region }},
+	{"code_greffe", func(d *models.BusinessData) interface{} { return d.CodeGreffe }},
+	{"greffe", func(d *models.BusinessData) interface{} { return d.Greffe }},
+	{"registration_date", func(d *models.BusinessData) interface{} { return d.RegistrationDate }},
+	{"deregistration_date", func(d *models.BusinessData) interface{} { return d.DeregistrationDate }},
+	{"status", func(d *models.BusinessData) interface{} { return d.Status }},
+	{"publication_date", func(d *models.BusinessData) interface{} { return d.PublicationDate }},
+	{"millesime_1", func(d *models.BusinessData) interface{} { return d.Millesime1 }},
+	{"closing_date_1", func(d *models.BusinessData) interface{} { return d.DateCloseEx1 }},
+	{"duration_months_1", func(d *models.BusinessData) interface{} { return numberOrText(d.DurationEx1) }},
+	{"revenue_1", func(d *models.BusinessData) interface{} { return numberOrText(d.CA1) }},
+	{"profits_1", func(d *models.BusinessData) interface{} { return numberOrText(d.Result1) }},
+	{"employees_1", func(d *models.BusinessData) interface{} { return numberOrText(d.Employees1) }},
+	{"millesime_2", func(d *models.BusinessData) interface{} { return d.Millesime2 }},
+	{"closing_date_2", func(d *models.BusinessData) interface{} { return d.DateCloseEx2 }},
+	{"duration_months_2", func(d *models.BusinessData) interface{} { return numberOrText(d.DurationEx2) }},
+	{"revenue_2", func(d *models.BusinessData) interface{} { return numberOrText(d.CA2) }},
+	{"profits_2", func(d *models.BusinessData) interface{} { return numberOrText(d.Result2) }},
+	{"employees_2", func(d *models.BusinessData) interface{} { return numberOrText(d.Employees2) }},
+	{"millesime_3", func(d *models.BusinessData) interface{} { return d.Millesime3 }},
+	{"closing_date_3", func(d *models.BusinessData) interface{} { return d.DateCloseEx3 }},
+	{"duration_months_3", func(d *models.BusinessData) interface{} { return numberOrText(d.DurationEx3) }},
+	{"revenue_3", func(d *models.BusinessData) interface{} { return numberOrText(d.CA3) }},
+	{"profits_3", func(d *models.BusinessData) interface{} { return numberOrText(d.Result3) }},
+	{"employees_3", func(d *models.BusinessData) interface{} { return numberOrText(d.Employees3) }},
+	{"revenue_range_1", func(d *models.BusinessData) interface{} { return d.RangeCA1 }},
+	{"revenue_range_2", func(d *models.BusinessData) interface{} { return d.RangeCA2 }},
+	{"revenue_range_3", func(d *models.BusinessData) interface{} { return d.RangeCA3 }},
+}
+
+// numberOrText returns a numeric field of the competition file as a number, or as is when it
+// is not one, such as "Confidentiel". Empty fields are empty cells.
+func numberOrText(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+// competitionTable lists the competitors of a competition data response with their financial
+// data, one per row. Competitors missing from the competition file have empty financial columns.
+func competitionTable(response interface{}) export.Table {
+	results, withFeature := listedResults(response)
+	table := export.Table{
+		Name:    "competition",
+		Columns: []string{"siret", "name", "naf_code", "address", "latitude", "longitude", "siren", "nic"},
+	}
+	for _, column := range competitorDataColumns {
+		table.Columns = append(table.Columns, column.name)
+	}
+	if withFeature {
+		table.Columns = append(append([]string{}, featureColumns...), table.Columns...)
+	}
+
+	for _, result := range results {
+		competitors, _ := result.result.([]models.CompetitorDetails)
+		for _, competitor := range competitors {
+			business := competitor.Business
+			row := []interface{}{business.Siret, business.Name, business.NAFCode, business.Address, business.Latitude, business.Longitude}
+			if competitor.Data != nil {
+				row = append(row, competitor.Data.Siren, competitor.Data.NIC)
+				for _, column := range competitorDataColumns {
+					row = append(row, column.value(competitor.Data))
+				}
+			}
+			if withFeature {
+				row = append(featureCells(result), row...)
+			}
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	return table
+}
+
+// irisSummaryRows are the statistics of an IRIS response listed before its RawData keys
+var irisSummaryRows = []struct {
+	key   string
+	label string
+	value func(response *models.IrisResponse) float64
+}{
+	{"area_km2", "Area (km²)", func(r *models.IrisResponse) float64 { return r.AreaKm2 }},
+	{"population_density", "Population density (inhabitants per km²)", func(r *models.IrisResponse) float64 { return r.PopulationDensity }},
+	{"median_income", "Average median income (€)", func(r *models.IrisResponse) float64 { return r.Data.MedianIncome.AverageIncome }},
+	{"median_income_area_covered", "Area covered by income data (%)", func(r *models.IrisResponse) float64 { return r.Data.MedianIncome.PercentageAreaCovered }},
+}
+
+// irisTable lists the statistics of an IRIS data response, one per row with a value column
+// per area: the single geometry, or every feature followed by their union.
+// Statistics and indicators keep the precision of the JSON response, set by the request, and
+// summary rows are rounded to two decimals. Indicators missing from an area are left empty.
+func irisTable(response interface{}) export.Table {
+	var results []areaResult
+	table := export.Table{
+		Name:    "iris",
+		Columns: []string{"key", "label"},
+	}
+	if collection, isCollection := response.(*models.FeatureCollectionResponse); isCollection {
+		results = featureResults(collection)
+		for _, result := range results {
+			if result.id != nil {
+				table.Columns = append(table.Columns, fmt.Sprintf("feature_%v", result.id))
+			} else {
+				table.Columns = append(table.Columns, fmt.Sprintf("feature_%d", result.feature))
+			}
+		}
+		if collection.Combined != nil {
+			results = append(results, areaResult{result: collection.Combined})
+			table.Columns = append(table.Columns, "combined")
+		}
+	} else {
+		results = []areaResult{{result: response}}
+		table.Columns = append(table.Columns, "value")
+	}
+
+	responses := make([]*models.IrisResponse, len(results))
+	for i, result := range results {
+		responses[i], _ = result.result.(*models.IrisResponse)
+	}
+
+	for _, summary := range irisSummaryRows {
+		row := []interface{}{summary.key, summary.label}
+		for _, irisResponse := range responses {
+			row = append(row, roundTo(summary.value(irisResponse), 2))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	for _, key := range irisStatisticKeys(responses) {
+		row := []interface{}{key, models.IrisStatisticLabel(key)}
+		for _, irisResponse := range responses {
+			// Indicators with a zero denominator are missing from some areas
+			value, exists := irisResponse.Data.OtherData[key]
+			if !exists {
+				row = append(row, nil)
+				continue
+			}
+			row = append(row, value)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// irisStatisticKeys returns the statistic keys of the responses, labelled keys first in the
+// order of the IRIS file, then any other key in alphabetical order
+func irisStatisticKeys(responses []*models.IrisResponse) []string {
+	present := make(map[string]bool)
+	for _, irisResponse := range responses {
+		for key := range irisResponse.Data.OtherData {
+			present[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(present))
+	for _, label := range models.IrisStatisticLabels {
+		if present[label.Key] {
+			keys = append(keys, label.Key)
+			delete(present, label.Key)
+		}
+	}
+	others := make([]string, 0, len(present))
+	for key := range present {
+		others = append(others, key)
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+// roundTo rounds a value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"csv-processor/internal/export"
+)
+
+// Response formats. JSON is accepted by every endpoint, the others by the endpoints listing them.
+const (
+	formatJSON    = "json"
+	formatGeoJSON = "geojson"
+	formatNDJSON  = "ndjson"
+	formatCSV     = "csv"
+	formatXLSX    = "xlsx"
+)
+
+// geoJSONContentType is the media type of GeoJSON responses (RFC 7946)
+const geoJSONContentType = "application/geo+json"
+
+// formatMediaTypes maps the formats to the media types selecting them in the Accept header
+var formatMediaTypes = map[string]string{
+	formatGeoJSON: geoJSONContentType,
+	formatNDJSON:  ndjsonContentType,
+	formatCSV:     "text/csv",
+	formatXLSX:    export.XLSXContentType,
+}
+
+// responseFormat returns the format requested by the format query parameter or, when it is
+// absent, by the Accept header. JSON is the default.
+func responseFormat(r *http.Request, allowed ...string) (string, *apiError) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if format == formatJSON || slices.Contains(allowed, format) {
+			return format, nil
+		}
+		return "", (&apiError{
+			Code:    "invalid_format",
+			Message: fmt.Sprintf("Format must be one of: %s", strings.Join(append([]string{formatJSON}, allowed...), ", ")),
+		}).withDetail("format", format)
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		for _, format := range allowed {
+			if formatMediaTypes[format] == mediaType {
+				return format, nil
+			}
+		}
+	}
+	return formatJSON, nil
+}
+
+// csvOptions returns the CSV conventions requested by the locale query parameter
+func csvOptions(r *http.Request) (export.CSVOptions, *apiError) {
+	switch locale := r.URL.Query().Get("locale"); locale {
+	case "", "en":
+		return export.CSVOptions{}, nil
+	case "fr":
+		return export.CSVOptions{French: true}, nil
+	default:
+		return export.CSVOptions{}, (&apiError{
+			Code:    "invalid_locale",
+			Message: "Locale must be en or fr",
+		}).withDetail("locale", locale)
+	}
+}
+
+// writeTable writes a table as a CSV or XLSX attachment named after the table
+func writeTable(w http.ResponseWriter, format string, options export.CSVOptions, table export.Table) {
+	// Build the file first so that a failure can still be reported as an error response
+	var buf bytes.Buffer
+	var err error
+	contentType := export.XLSXContentType
+	if format == formatCSV {
+		contentType = "text/csv; charset=utf-8"
+		err = export.WriteCSV(&buf, table, options)
+	} else {
+		err = export.WriteXLSX(&buf, table)
+	}
+	if err != nil {
+		log.Printf("Error writing %s export: %v", format, err)
+		writeError(w, http.StatusInternalServerError, &apiError{Code: "internal_error", Message: "Error writing export"})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, table.Name, format))
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"csv-processor/internal/models"
+	"csv-processor/internal/services"
+)
+
+// queryFeature creates the feature of a searched area, with the properties of the request feature if any
+func queryFeature(id interface{}, properties map[string]interface{}, geometry interface{}) models.GeoJSONFeature {
+	featureProperties := make(map[string]interface{}, len(properties)+1)
+	for key, value := range properties {
+		featureProperties[key] = value
+	}
+	featureProperties["kind"] = "query"
+
+	return models.GeoJSONFeature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   geometry,
+		Properties: featureProperties,
+	}
+}
+
+// searchGeoJSON builds the GeoJSON response of a search from its business lists
+func searchGeoJSON(response interface{}, req models.SearchRequest, area *models.ResolvedArea) models.GeoJSONFeatureCollection {
+	if collection, isCollection := response.(*models.FeatureCollectionResponse); isCollection {
+		return featuresGeoJSON(collection, req)
+	}
+	businesses, _ := response.([]*models.Business)
+	return businessesGeoJSON(businesses, req, area)
+}
+
+// businessesGeoJSON builds the GeoJSON response of a single area search, the searched area
+// being added as the last feature when the request asks for it
+func businessesGeoJSON(businesses []*models.Business, req models.SearchRequest, area *models.ResolvedArea) models.GeoJSONFeatureCollection {
+	collection := models.GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]models.GeoJSONFeature, 0, len(businesses)+1),
+	}
+	for _, business := range businesses {
+		collection.Features = append(collection.Features, models.NewBusinessFeature(business))
+	}
+
+	if req.IncludeQueryGeometry {
+		if req.Center != nil {
+			collection.Features = append(collection.Features, queryFeature(nil, map[string]interface{}{
+				"center": req.Center,
+				"radius": req.Radius,
+			}, services.GeodesicCircle(*req.Center, req.Radius)))
+		} else if area != nil {
+			collection.Features = append(collection.Features, queryFeature(nil, map[string]interface{}{
+				"areaType": area.Type,
+				"code":     area.Code,
+				"name":     area.Name,
+			}, req.Geometry))
+		} else {
+			collection.Features = append(collection.Features, queryFeature(nil, nil, req.Geometry))
+		}
+	}
+	return collection
+}
+
+// featuresGeoJSON builds the GeoJSON response of a FeatureCollection search whose results are
+// business lists. Each business carries the index of the request feature it was found in,
+// unless the results were combined, in which case only the businesses of the union are listed.
+func featuresGeoJSON(response *models.FeatureCollectionResponse, req models.SearchRequest) models.GeoJSONFeatureCollection {
+	collection := models.GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []models.GeoJSONFeature{},
+	}
+
+	if req.Combined {
+		businesses, _ := response.Combined.([]*models.Business)
+		for _, business := range businesses {
+			collection.Features = append(collection.Features, models.NewBusinessFeature(business))
+		}
+	} else {
+		for i, result := range response.Features {
+			businesses, _ := result.Result.([]*models.Business)
+			for _, business := range businesses {
+				feature := models.NewBusinessFeature(business)
+				feature.Properties["feature"] = i
+				if result.ID != nil {
+					feature.Properties["featureId"] = result.ID
+				}
+				collection.Features = append(collection.Features, feature)
+			}
+		}
+	}
+
+	if req.IncludeQueryGeometry {
+		for i, feature := range req.Features {
+			query := queryFeature(feature.ID, feature.Properties, feature.Geometry)
+			query.Properties["feature"] = i
+			collection.Features = append(collection.Features, query)
+		}
+	}
+	return collection
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/models"
+	"csv-processor/internal/services"
+)
+
+// Limits applied to request geometries. Positions must also fall in the configured bounds.
+const (
+	// Minimum number of positions of a closed ring
+	minRingPositions = 4
+	// Maximum number of positions of a request geometry, before simplification
+	maxGeometryPositions = 20000
+	// Maximum radius accepted for radius searches, in meters
+	maxRadiusMeters = 50000
+)
+
+// decodeGeometryRequest decodes the request body into body, then validates and
+// simplifies the geometry of req, which must be part of body. It writes the error response
+// and returns false when the request is rejected.
+func decodeGeometryRequest(w http.ResponseWriter, r *http.Request, body interface{}, req *models.GeometryRequest) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeRequestError(w, &apiError{
+			Code:    "invalid_request",
+			Message: fmt.Sprintf("Invalid request format: %v", err),
+		})
+		return false
+	}
+
+	if err := prepareGeometryRequest(req); err != nil {
+		writeRequestError(w, err)
+		return false
+	}
+	return true
+}
+
+// prepareGeometryRequest validates the geometry of a request and simplifies its polygons
+func prepareGeometryRequest(req *models.GeometryRequest) *apiError {
+	if req.Area != nil {
+		// Area request, the geometry is resolved once the datasets are known
+		return validateArea(req)
+	}
+	if req.Center != nil {
+		// Radius search, the geometry is not used
+		return validateRadius(req.Center, req.Radius)
+	}
+
+	switch req.Type {
+	case "FeatureCollection":
+		if len(req.Features) == 0 {
+			return &apiError{Code: "missing_geometry", Message: "GeoJSON feature is required"}
+		}
+		for i := range req.Features {
+			if err := validateGeometry(req.Features[i].Geometry); err != nil {
+				return err.withDetail("feature", i)
+			}
+			// Simplify the polygon coordinates
+			req.Features[i].Geometry.Coordinates = simplifyGeoJSONGeometry(req.Features[i].Geometry.Coordinates)
+		}
+	case "Feature":
+		if err := validateGeometry(req.Geometry); err != nil {
+			return err
+		}
+		// Simplify the polygon coordinates
+		req.Geometry.Coordinates = simplifyGeoJSONGeometry(req.Geometry.Coordinates)
+	default:
+		return &apiError{
+			Code:    "invalid_geojson_type",
+			Message: "Invalid GeoJSON type. Must be either 'Feature' or 'FeatureCollection'",
+		}
+	}
+	return nil
+}
+
+// validateArea checks the administrative area of a request, which replaces its geometry
+func validateArea(req *models.GeometryRequest) *apiError {
+	if req.Center != nil {
+		return &apiError{Code: "conflicting_area", Message: "Area cannot be combined with a center"}
+	}
+	if !slices.Contains(services.AreaTypes, req.Area.Type) {
+		return (&apiError{
+			Code:    "invalid_area_type",
+			Message: fmt.Sprintf("Area type must be one of: %s", strings.Join(services.AreaTypes, ", ")),
+		}).withDetail("type", req.Area.Type)
+	}
+	if strings.TrimSpace(req.Area.Code) == "" {
+		return &apiError{Code: "missing_area_code", Message: "Area code is required"}
+	}
+	return nil
+}
+
+// resolveArea replaces the administrative area of a request by its geometry, simplified as a
+// drawn polygon would be. It returns the resolved area, nil for requests without an area.
+func resolveArea(ctx context.Context, csvService *services.CSVService, req *models.GeometryRequest) (*models.ResolvedArea, error) {
+	if req.Area == nil {
+		return nil, nil
+	}
+	area, err := csvService.ResolveArea(ctx, req.Area.Type, req.Area.Code)
+	if err != nil {
+		return nil, err
+	}
+	return useArea(req, area)
+}
+
+// useArea replaces the geometry of a request by the simplified geometry of its resolved area.
+// Area geometries come from the datasets, so they are checked as searched, like drawn ones.
+func useArea(req *models.GeometryRequest, area *models.ResolvedArea) (*models.ResolvedArea, error) {
+	// The resolved geometry is shared by the requests on the same area
+	resolved := *area
+	resolved.Geometry = &models.GeoJSONGeometry{
+		Type:        area.Geometry.Type,
+		Coordinates: simplifyGeoJSONGeometry(area.Geometry.Coordinates),
+	}
+	if err := validateGeometry(*resolved.Geometry); err != nil {
+		serviceErr := &services.ServiceError{
+			Kind:    services.ErrorInvalidGeometry,
+			Message: fmt.Sprintf("Geometry of %s %s cannot be searched: %s", area.Type, area.Code, err.Message),
+			Details: err.Details,
+		}
+		return nil, serviceErr.WithDetail("reason", err.Code)
+	}
+	req.Type = "Feature"
+	req.Geometry = *resolved.Geometry
+	return &resolved, nil
+}
+
+// withArea adds the resolved area of a request to its JSON response
+func withArea(response interface{}, area *models.ResolvedArea) interface{} {
+	if area == nil {
+		return response
+	}
+	switch response := response.(type) {
+	case models.SearchResponse:
+		response.Area = area
+		return response
+	case *models.CompetitionResponseByNAF:
+		response.Area = area
+	case *models.IrisResponse:
+		response.Area = area
+	}
+	return response
+}
+
+// validateRadius checks the center and radius of a radius search
+func validateRadius(center *models.Point, radius float64) *apiError {
+	if !config.GetBounds().Contains(center.Lng, center.Lat) {
+		return (&apiError{
+			Code:    "out_of_bounds",
+			Message: "Center is outside of France",
+		}).withDetail("coordinate", []float64{center.Lng, center.Lat})
+	}
+	if radius <= 0 || radius > maxRadiusMeters {
+		return (&apiError{
+			Code:    "invalid_radius",
+			Message: fmt.Sprintf("Radius must be between 0 and %d meters", maxRadiusMeters),
+		}).withDetail("radius", radius)
+	}
+	return nil
+}
+
+// validateGeometry checks that a geometry is a Polygon or MultiPolygon made of valid rings
+func validateGeometry(geometry models.GeoJSONGeometry) *apiError {
+	if !geometry.IsPolygonal() {
+		return (&apiError{
+			Code:    "unsupported_geometry",
+			Message: "Only Polygon and MultiPolygon geometry types are supported",
+		}).withDetail("type", geometry.Type)
+	}
+	if len(geometry.Coordinates) == 0 {
+		return &apiError{Code: "empty_geometry", Message: "Geometry has no coordinates"}
+	}
+
+	bounds := config.GetBounds()
+	positions := 0
+	for i, rings := range geometry.Coordinates {
+		if len(rings) == 0 {
+			return (&apiError{Code: "empty_geometry", Message: "Polygon has no rings"}).withDetail("polygon", i)
+		}
+		for j, ring := range rings {
+			positions += len(ring)
+			if positions > maxGeometryPositions {
+				return (&apiError{
+					Code:    "too_many_vertices",
+					Message: fmt.Sprintf("Geometry has more than %d vertices", maxGeometryPositions),
+				}).withDetail("max_vertices", maxGeometryPositions)
+			}
+			if err := validateRing(ring, bounds); err != nil {
+				return err.withDetail("polygon", i).withDetail("ring", j)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRing checks the positions, closure, vertex count and simplicity of a ring
+func validateRing(ring [][]float64, bounds config.Bounds) *apiError {
+	for k, coord := range ring {
+		if len(coord) < 2 || math.IsNaN(coord[0]) || math.IsNaN(coord[1]) || math.IsInf(coord[0], 0) || math.IsInf(coord[1], 0) {
+			return (&apiError{
+				Code:    "invalid_coordinate",
+				Message: fmt.Sprintf("Invalid position %d", k),
+			}).withDetail("position", k)
+		}
+		if !bounds.Contains(coord[0], coord[1]) {
+			return (&apiError{
+				Code:    "out_of_bounds",
+				Message: fmt.Sprintf("Position %d is outside of France", k),
+			}).withDetail("position", k).withDetail("coordinate", coord[:2])
+		}
+	}
+
+	if len(ring) < minRingPositions {
+		return (&apiError{
+			Code:    "too_few_vertices",
+			Message: fmt.Sprintf("Ring must have at least %d positions", minRingPositions),
+		}).withDetail("positions", len(ring))
+	}
+
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return (&apiError{
+			Code:    "unclosed_ring",
+			Message: "Ring is not closed, its first and last positions must be equal",
+		}).withDetail("position", len(ring)-1).withDetail("coordinate", last[:2])
+	}
+
+	if k, ok := ringSelfIntersection(ring); ok {
+		return (&apiError{
+			Code:    "self_intersection",
+			Message: fmt.Sprintf("Ring intersects itself near position %d", k),
+		}).withDetail("position", k).withDetail("coordinate", ring[k][:2])
+	}
+	return nil
+}
+
+// ringSegment is a segment of a ring, starting at the given ring position
+type ringSegment struct {
+	start, end [2]float64
+	position   int
+}
+
+// ringSelfIntersection returns the position at which a closed ring intersects itself,
+// if it does. Segments are swept by increasing x so only overlapping ones are compared.
+func ringSelfIntersection(ring [][]float64) (int, bool) {
+	// Skip repeated positions, they would make adjacent segments look like crossings
+	segments := make([]ringSegment, 0, len(ring))
+	for k := 0; k < len(ring)-1; k++ {
+		start := [2]float64{ring[k][0], ring[k][1]}
+		if len(segments) > 0 && segments[len(segments)-1].start == start {
+			segments[len(segments)-1].position = k
+			continue
+		}
+		segments = append(segments, ringSegment{start: start, position: k})
+	}
+	for i := range segments {
+		segments[i].end = segments[(i+1)%len(segments)].start
+	}
+	if len(segments) < 3 {
+		return 0, false
+	}
+
+	order := make([]int, len(segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return math.Min(segments[order[a]].start[0], segments[order[a]].end[0]) <
+			math.Min(segments[order[b]].start[0], segments[order[b]].end[0])
+	})
+
+	last := len(segments) - 1
+	for a, i := range order {
+		maxX := math.Max(segments[i].start[0], segments[i].end[0])
+		for _, j := range order[a+1:] {
+			if math.Min(segments[j].start[0], segments[j].end[0]) > maxX {
+				break
+			}
+			// Adjacent segments share an endpoint
+			if j == i+1 || i == j+1 || (i == 0 && j == last) || (j == 0 && i == last) {
+				continue
+			}
+			if segmentsIntersect(segments[i], segments[j]) {
+				if segments[i].position > segments[j].position {
+					return segments[i].position, true
+				}
+				return segments[j].position, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// segmentsIntersect reports whether two segments cross or touch
+func segmentsIntersect(s1, s2 ringSegment) bool {
+	d1 := orientation(s2.start, s2.end, s1.start)
+	d2 := orientation(s2.start, s2.end, s1.end)
+	d3 := orientation(s1.start, s1.end, s2.start)
+	d4 := orientation(s1.start, s1.end, s2.end)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+
+	// Collinear or touching cases
+	return (d1 == 0 && onSegment(s2.start, s2.end, s1.start)) ||
+		(d2 == 0 && onSegment(s2.start, s2.end, s1.end)) ||
+		(d3 == 0 && onSegment(s1.start, s1.end, s2.start)) ||
+		(d4 == 0 && onSegment(s1.start, s1.end, s2.end))
+}
+
+// orientation returns the sign of the cross product of (b - a) and (c - a)
+func orientation(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment reports whether c, collinear with a and b, lies between them
+func onSegment(a, b, c [2]float64) bool {
+	return c[0] >= math.Min(a[0], b[0]) && c[0] <= math.Max(a[0], b[0]) &&
+		c[1] >= math.Min(a[1], b[1]) && c[1] <= math.Max(a[1], b[1])
+}
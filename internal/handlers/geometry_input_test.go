@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"csv-processor/internal/models"
+	"csv-processor/internal/services"
+)
+
+func TestValidateGeometryRings(t *testing.T) {
+	square := [][]float64{{2.0, 48.0}, {2.1, 48.0}, {2.1, 48.1}, {2.0, 48.1}, {2.0, 48.0}}
+	hole := [][]float64{{2.02, 48.02}, {2.02, 48.08}, {2.08, 48.08}, {2.08, 48.02}, {2.02, 48.02}}
+
+	tests := []struct {
+		name    string
+		rings   [][][]float64
+		code    string
+		message string
+	}{
+		{
+			name:    "unclosed ring",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.1, 48.1}, {2.0, 48.1}}},
+			code:    "unclosed_ring",
+			message: "Ring is not closed, its first and last positions must be equal",
+		},
+		{
+			name:    "too few positions",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.0, 48.0}}},
+			code:    "too_few_vertices",
+			message: "Ring must have at least 4 positions",
+		},
+		{
+			name:    "longitude out of range",
+			rings:   [][][]float64{{{2.0, 48.0}, {200.0, 48.0}, {2.1, 48.1}, {2.0, 48.0}}},
+			code:    "out_of_bounds",
+			message: "Position 1 is outside of France",
+		},
+		{
+			name:    "latitude out of range",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.1, 95.0}, {2.0, 48.0}}},
+			code:    "out_of_bounds",
+			message: "Position 2 is outside of France",
+		},
+		{
+			name:    "invalid coordinate",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1}, {2.1, 48.1}, {2.0, 48.0}}},
+			code:    "invalid_coordinate",
+			message: "Invalid position 1",
+		},
+		{
+			name:  "repeated consecutive positions",
+			rings: [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.1, 48.0}, {2.1, 48.1}, {2.0, 48.1}, {2.0, 48.1}, {2.0, 48.0}}},
+		},
+		{
+			name:    "bow-tie",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1, 48.1}, {2.1, 48.0}, {2.0, 48.1}, {2.0, 48.0}}},
+			code:    "self_intersection",
+			message: "Ring intersects itself near position 2",
+		},
+		{
+			name:  "ring with a hole",
+			rings: [][][]float64{square, hole},
+		},
+		{
+			name:  "valid ring",
+			rings: [][][]float64{square},
+		},
+		{
+			name:  "overseas department",
+			rings: [][][]float64{{{55.4, -21.0}, {55.5, -21.0}, {55.5, -20.9}, {55.4, -20.9}, {55.4, -21.0}}},
+		},
+		{
+			name:    "between the bounds",
+			rings:   [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.1, 48.1}, {-3.7, 40.4}, {2.0, 48.0}}},
+			code:    "out_of_bounds",
+			message: "Position 3 is outside of France",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geometry := models.GeoJSONGeometry{Type: "Polygon", Coordinates: [][][][]float64{tt.rings}}
+			err := validateGeometry(geometry)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("validateGeometry() = %s %q, want no error", err.Code, err.Message)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("validateGeometry() = nil, want %s", tt.code)
+			}
+			if err.Code != tt.code || err.Message != tt.message {
+				t.Errorf("validateGeometry() = %s %q, want %s %q", err.Code, err.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateRadius(t *testing.T) {
+	tests := []struct {
+		name   string
+		center models.Point
+		radius float64
+		code   string
+	}{
+		{"Paris", models.Point{Lat: 48.8566, Lng: 2.3522}, 1000, ""},
+		{"Corsica", models.Point{Lat: 41.9192, Lng: 8.7386}, 1000, ""},
+		{"Guadeloupe", models.Point{Lat: 16.2411, Lng: -61.5331}, 1000, ""},
+		{"Mayotte", models.Point{Lat: -12.7806, Lng: 45.2278}, 1000, ""},
+		{"largest radius", models.Point{Lat: 48.8566, Lng: 2.3522}, maxRadiusMeters, ""},
+		{"radius over the cap", models.Point{Lat: 48.8566, Lng: 2.3522}, maxRadiusMeters + 1, "invalid_radius"},
+		{"zero radius", models.Point{Lat: 48.8566, Lng: 2.3522}, 0, "invalid_radius"},
+		{"negative radius", models.Point{Lat: 48.8566, Lng: 2.3522}, -10, "invalid_radius"},
+		{"center outside of France", models.Point{Lat: 40.7128, Lng: -74.0060}, 1000, "out_of_bounds"},
+		{"center out of range", models.Point{Lat: 95, Lng: 2.3522}, 1000, "out_of_bounds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRadius(&tt.center, tt.radius)
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("validateRadius() = %s %q, want no error", err.Code, err.Message)
+			case tt.code != "" && (err == nil || err.Code != tt.code):
+				t.Errorf("validateRadius() = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestUseArea(t *testing.T) {
+	square := [][][]float64{{{2.0, 48.0}, {2.1, 48.0}, {2.1, 48.1}, {2.0, 48.1}, {2.0, 48.0}}}
+	// A commune outside of the configured bounds, as a dataset error would produce
+	spain := [][][]float64{{{-3.8, 40.3}, {-3.6, 40.3}, {-3.6, 40.5}, {-3.8, 40.5}, {-3.8, 40.3}}}
+
+	tests := []struct {
+		name    string
+		polygon [][][]float64
+		reason  string
+	}{
+		{"valid geometry", square, ""},
+		{"geometry out of bounds", spain, "out_of_bounds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			area := &models.ResolvedArea{
+				Type:     "commune",
+				Code:     "75056",
+				Geometry: &models.GeoJSONGeometry{Type: "Polygon", Coordinates: [][][][]float64{tt.polygon}},
+			}
+			req := &models.GeometryRequest{Area: &models.AreaCode{Type: area.Type, Code: area.Code}}
+			resolved, err := useArea(req, area)
+
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("useArea() error = %v", err)
+				}
+				if req.Type != "Feature" || resolved.Geometry == area.Geometry {
+					t.Errorf("useArea() left the request as %s, want a Feature with a copy of the geometry", req.Type)
+				}
+				return
+			}
+			var serviceErr *services.ServiceError
+			if !errors.As(err, &serviceErr) || serviceErr.Kind != services.ErrorInvalidGeometry || serviceErr.Details["reason"] != tt.reason {
+				t.Fatalf("useArea() error = %v, want an invalid geometry error for %s", err, tt.reason)
+			}
+			if req.Type != "" {
+				t.Errorf("request type = %q, want the request left unchanged", req.Type)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math"
 	"net/http"
@@ -9,7 +12,9 @@ import (
 	// "os"
 	"time"
 
+	"csv-processor/internal/config"
 	"csv-processor/internal/models"
+	"csv-processor/internal/results"
 	"csv-processor/internal/services"
 )
 
@@ -29,15 +34,21 @@ const (
 	baseEpsilonPercent = 0.1 // 0.1% of the diagonal
 )
 
-// calculatePolygonArea calculates the area of a polygon using the shoelace formula
+// calculatePolygonArea calculates the area of a polygon in km² using the shoelace formula
+// on its Lambert-93 projection
 func calculatePolygonArea(points []Point) float64 {
+	projected := make([]Point, len(points))
+	for i, p := range points {
+		projected[i].X, projected[i].Y = models.ToLambert93(p.X, p.Y)
+	}
+
 	area := 0.0
-	j := len(points) - 1
-	for i := 0; i < len(points); i++ {
-		area += (points[j].X + points[i].X) * (points[j].Y - points[i].Y)
+	j := len(projected) - 1
+	for i := 0; i < len(projected); i++ {
+		area += (projected[j].X + projected[i].X) * (projected[j].Y - projected[i].Y)
 		j = i
 	}
-	return math.Abs(area) / 2
+	return math.Abs(area) / 2 / 1e6
 }
 
 // calculateBoundingBoxDiagonal calculates the diagonal length of the polygon's bounding box
@@ -82,11 +93,12 @@ func calculatePolygonComplexity(points []Point) (bool, float64) {
 	// Calculate polygon area
 	area := calculatePolygonArea(points)
 	
-	// Calculate points per unit area
+	// Calculate points per km²
 	pointsPerArea := float64(numPoints) / area
 	
 	// Determine if simplification is needed
-	needsSimplification := numPoints > maxPoints || pointsPerArea > 700
+	// The maximum point density, in points per km², comes from the configuration
+	needsSimplification := numPoints > maxPoints || pointsPerArea > config.GetSimplificationConfig().MaxPointsPerKm2
 
 	if !needsSimplification {
 		return false, 0
@@ -162,15 +174,15 @@ func simplifyPolygon(points []Point, epsilon float64) []Point {
 	return []Point{points[0], points[len(points)-1]}
 }
 
-// simplifyGeoJSONPolygon simplifies a GeoJSON polygon using the Ramer-Douglas-Peucker algorithm
-func simplifyGeoJSONPolygon(coordinates [][][]float64) [][][]float64 {
-	if len(coordinates) == 0 || len(coordinates[0]) == 0 {
-		return coordinates
+// simplifyGeoJSONRing simplifies a single GeoJSON ring using the Ramer-Douglas-Peucker algorithm
+func simplifyGeoJSONRing(ring [][]float64) [][]float64 {
+	if len(ring) == 0 {
+		return ring
 	}
 
 	// Convert coordinates to points
-	points := make([]Point, len(coordinates[0]))
-	for i, coord := range coordinates[0] {
+	points := make([]Point, len(ring))
+	for i, coord := range ring {
 		points[i] = Point{X: coord[0], Y: coord[1]}
 	}
 
@@ -178,21 +190,35 @@ func simplifyGeoJSONPolygon(coordinates [][][]float64) [][][]float64 {
 	needsSimplification, epsilon := calculatePolygonComplexity(points)
 	
 	if !needsSimplification {
-		return coordinates
+		return ring
 	}
 
 	// Simplify the points
 	simplifiedPoints := simplifyPolygon(points, epsilon)
 
+	// A closed ring needs at least 4 points, keep the original if it collapsed
+	if len(simplifiedPoints) < 4 {
+		return ring
+	}
+
 	// Convert back to GeoJSON format
-	simplifiedCoords := make([][][]float64, 1)
-	simplifiedCoords[0] = make([][]float64, len(simplifiedPoints))
+	simplifiedRing := make([][]float64, len(simplifiedPoints))
 	for i, point := range simplifiedPoints {
-		simplifiedCoords[0][i] = []float64{point.X, point.Y}
+		simplifiedRing[i] = []float64{point.X, point.Y}
 	}
 
 	// Log simplification results
-	log.Printf("Polygon simplified from %d to %d points (epsilon: %f)", len(points), len(simplifiedPoints), epsilon)
+	log.Printf("Ring simplified from %d to %d points (epsilon: %f)", len(points), len(simplifiedPoints), epsilon)
+
+	return simplifiedRing
+}
+
+// simplifyGeoJSONPolygon simplifies every ring of a GeoJSON polygon, keeping its holes
+func simplifyGeoJSONPolygon(coordinates [][][]float64) [][][]float64 {
+	simplifiedCoords := make([][][]float64, len(coordinates))
+	for i, ring := range coordinates {
+		simplifiedCoords[i] = simplifyGeoJSONRing(ring)
+	}
 
 	// // output the geojson to a file
 	// geojsonStr, _ := json.Marshal(simplifiedCoords)
@@ -201,99 +227,207 @@ func simplifyGeoJSONPolygon(coordinates [][][]float64) [][][]float64 {
 	return simplifiedCoords
 }
 
+// simplifyGeoJSONGeometry simplifies every polygon of a GeoJSON Polygon or MultiPolygon
+func simplifyGeoJSONGeometry(coordinates [][][][]float64) [][][][]float64 {
+	simplifiedCoords := make([][][][]float64, len(coordinates))
+	for i, polygon := range coordinates {
+		simplifiedCoords[i] = simplifyGeoJSONPolygon(polygon)
+	}
+	return simplifiedCoords
+}
+
+// writeJSON writes a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
+
+// requestContext returns the context of the request bounded by the endpoint deadline, when there is one
+func requestContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// processFeatures computes the result of every feature of a FeatureCollection and,
+// when combined is set, the result over the union of all features
+func processFeatures(csvService *services.CSVService, features []models.Feature, combined bool, process func(geometry models.GeoJSONGeometry) (interface{}, error)) (*models.FeatureCollectionResponse, error) {
+	response := &models.FeatureCollectionResponse{
+		Features: make([]models.FeatureResult, 0, len(features)),
+	}
+	geometries := make([]models.GeoJSONGeometry, 0, len(features))
+
+	for i, feature := range features {
+		result, err := process(feature.Geometry)
+		if err != nil {
+			// Tell the client which feature failed
+			var serviceErr *services.ServiceError
+			if errors.As(err, &serviceErr) {
+				serviceErr.WithDetail("feature", i)
+			}
+			return nil, fmt.Errorf("error processing feature %d: %w", i, err)
+		}
+		response.Features = append(response.Features, models.FeatureResult{
+			ID:         feature.ID,
+			Properties: feature.Properties,
+			Result:     result,
+		})
+		geometries = append(geometries, feature.Geometry)
+	}
+
+	if combined {
+		union, err := csvService.UnionGeoJSON(geometries)
+		if err != nil {
+			return nil, fmt.Errorf("error merging features: %w", err)
+		}
+		response.Combined, err = process(*union)
+		if err != nil {
+			return nil, fmt.Errorf("error processing merged features: %w", err)
+		}
+	}
+
+	return response, nil
+}
+
+// groupBusinessesByNAF builds the search response grouping businesses by NAF code
+func groupBusinessesByNAF(businesses []*models.Business) models.SearchResponse {
+	businessesByNAF := make(map[string][]*models.Business)
+	for _, business := range businesses {
+		businessesByNAF[business.NAFCode] = append(businessesByNAF[business.NAFCode], business)
+	}
+
+	// Create response with grouped businesses
+	nafResponses := make([]models.NAFCodeResponse, 0, len(businessesByNAF))
+	for nafCode, businesses := range businessesByNAF {
+		nafResponses = append(nafResponses, models.NAFCodeResponse{
+			NAFCode:           nafCode,
+			NumberOfBusinesses: len(businesses),
+			Businesses:        businesses,
+		})
+	}
+
+	return models.SearchResponse{
+		NAFCodes: nafResponses,
+	}
+}
+
 // SearchHandler handles search requests
 type SearchHandler struct {
-	csvService *services.CSVService
+	datasets *services.Datasets
+	store    results.Store
 }
 
 // NewSearchHandler creates a new SearchHandler instance
-func NewSearchHandler(csvService *services.CSVService) *SearchHandler {
+func NewSearchHandler(datasets *services.Datasets, store results.Store) *SearchHandler {
 	return &SearchHandler{
-		csvService: csvService,
+		datasets: datasets,
+		store:    store,
+	}
+}
+
+// searchGeometry searches for businesses within a GeoJSON geometry
+func searchGeometry(ctx context.Context, csvService *services.CSVService, geometry models.GeoJSONGeometry, nafCodes []string) ([]*models.Business, error) {
+	geojsonStr, err := json.Marshal(geometry)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding geometry: %v", err)
 	}
+	return csvService.SearchBusinesses(ctx, string(geojsonStr), nafCodes)
 }
 
-// HandleSearch handles the search request
+// HandleSearch handles the search request. Businesses are grouped by NAF code, or returned as a
+// GeoJSON FeatureCollection of points, as a CSV or XLSX list or as a stream of NDJSON lines when
+// the format parameter or the Accept header asks for it. Results are stored in their JSON form,
+// except streamed ones.
 func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	format, formatErr := responseFormat(r, formatGeoJSON, formatNDJSON, formatCSV, formatXLSX)
+	if formatErr != nil {
+		writeRequestError(w, formatErr)
+		return
+	}
+	exportOptions, localeErr := csvOptions(r)
+	if localeErr != nil {
+		writeRequestError(w, localeErr)
 		return
 	}
 
 	var req models.SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeGeometryRequest(w, r, &req, &req.GeometryRequest) {
 		return
 	}
 
+	// Stop processing when the client goes away or the endpoint deadline expires
+	ctx, cancel := requestContext(r, config.Timeout(config.GetTimeoutConfig().CompetitorSearch))
+	defer cancel()
+
 	// Validate request
 	if len(req.NAFCodes) == 0 {
-		http.Error(w, "At least one NAF code is required", http.StatusBadRequest)
+		writeRequestError(w, &apiError{Code: "missing_naf_codes", Message: "At least one NAF code is required"})
 		return
 	}
 
-	// Get geometry based on request type
-	var geometry interface{}
-	if req.Type == "FeatureCollection" {
-		if len(req.Features) == 0 {
-			http.Error(w, "GeoJSON feature is required", http.StatusBadRequest)
-			return
-		}
-		if req.Features[0].Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Features[0].Geometry.Coordinates = simplifyGeoJSONPolygon(req.Features[0].Geometry.Coordinates)
-		geometry = req.Features[0].Geometry
-	} else if req.Type == "Feature" {
-		if req.Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Geometry.Coordinates = simplifyGeoJSONPolygon(req.Geometry.Coordinates)
-		geometry = req.Geometry
-	} else {
-		http.Error(w, "Invalid GeoJSON type. Must be either 'Feature' or 'FeatureCollection'", http.StatusBadRequest)
+	// Requests run on the dataset generation current when they start
+	csvService, err := h.datasets.Service()
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
-
-	// Search for businesses
-	geojsonStr, _ := json.Marshal(geometry)
-	businesses, err := h.csvService.SearchBusinesses(string(geojsonStr), req.NAFCodes, true)
+	area, err := resolveArea(ctx, csvService, &req.GeometryRequest)
 	if err != nil {
-		http.Error(w, "Error processing request", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	// Group businesses by NAF code
-	businessesByNAF := make(map[string][]*models.Business)
-	for _, business := range businesses {
-		businessesByNAF[business.NAFCode] = append(businessesByNAF[business.NAFCode], business)
+	// Large searches are written as the scan finds the businesses
+	if format == formatNDJSON {
+		w.Header().Set("Vary", "Accept")
+		streamSearch(ctx, w, csvService, req)
+		log.Printf("Request processed in %v\n", time.Since(startTime))
+		return
 	}
 
-	// Create response with grouped businesses
-	nafResponses := make([]models.NAFCodeResponse, 0, len(businessesByNAF))
-	for nafCode, businesses := range businessesByNAF {
-		nafResponses = append(nafResponses, models.NAFCodeResponse{
-			NAFCode:           nafCode,
-			NumberOfBusinesses: len(businesses),
-			Businesses:        businesses,
+	// Search for businesses in every feature, or in the single geometry.
+	// Every format is built from the business lists.
+	var response interface{}
+	if req.Center == nil && req.Type == "FeatureCollection" {
+		response, err = processFeatures(csvService, req.Features, req.Combined, func(geometry models.GeoJSONGeometry) (interface{}, error) {
+			return searchGeometry(ctx, csvService, geometry, req.NAFCodes)
 		})
+	} else if req.Center != nil {
+		response, err = csvService.SearchBusinessesInRadius(ctx, *req.Center, req.Radius, req.NAFCodes)
+	} else {
+		response, err = searchGeometry(ctx, csvService, req.Geometry, req.NAFCodes)
 	}
-
-	response := models.SearchResponse{
-		NAFCodes: nafResponses,
+	if err != nil {
+		writeServiceError(w, err)
+		return
 	}
+	jsonResponse := withArea(groupSearchResponse(response), area)
+	saveResult(w, h.store, results.KindSearch, req, jsonResponse)
 
 	// Return results
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		return
+	w.Header().Set("Vary", "Accept")
+	if format == formatJSON {
+		response = jsonResponse
+	}
+	if format == formatCSV || format == formatXLSX {
+		writeTable(w, format, exportOptions, competitorsTable(response))
+	} else {
+		contentType := "application/json"
+		if format == formatGeoJSON {
+			contentType = geoJSONContentType
+			response = searchGeoJSON(response, req, area)
+		}
+		w.Header().Set("Content-Type", contentType)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			return
+		}
 	}
 
 	// Log processing time
@@ -305,66 +439,62 @@ func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 func (h *SearchHandler) HandleCompetitorCount(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req models.SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeGeometryRequest(w, r, &req, &req.GeometryRequest) {
 		return
 	}
 
+	// Stop processing when the client goes away or the endpoint deadline expires
+	ctx, cancel := requestContext(r, config.Timeout(config.GetTimeoutConfig().CompetitorCount))
+	defer cancel()
+
 	// Validate request
 	if len(req.NAFCodes) == 0 {
-		http.Error(w, "At least one NAF code is required", http.StatusBadRequest)
+		writeRequestError(w, &apiError{Code: "missing_naf_codes", Message: "At least one NAF code is required"})
 		return
 	}
 
-	// Get geometry based on request type
-	var geometry interface{}
-	if req.Type == "FeatureCollection" {
-		if len(req.Features) == 0 {
-			http.Error(w, "GeoJSON feature is required", http.StatusBadRequest)
-			return
-		}
-		if req.Features[0].Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Features[0].Geometry.Coordinates = simplifyGeoJSONPolygon(req.Features[0].Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Features[0].Geometry
-	} else if req.Type == "Feature" {
-		if req.Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Geometry.Coordinates = simplifyGeoJSONPolygon(req.Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Geometry
-	} else {
-		http.Error(w, "Invalid GeoJSON type. Must be either 'Feature' or 'FeatureCollection'", http.StatusBadRequest)
+	// Requests run on the dataset generation current when they start
+	csvService, err := h.datasets.Service()
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
-
-	// Search for businesses
-	geojsonStr, _ := json.Marshal(geometry)
-	businesses, err := h.csvService.SearchBusinesses(string(geojsonStr), req.NAFCodes, false)
-	if err != nil {
-		http.Error(w, "Error processing request", http.StatusInternalServerError)
+	if _, err := resolveArea(ctx, csvService, &req.GeometryRequest); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
-	numberOfCompetitors := models.CompetitorCountResponse{
-		NumberOfCompetitors: len(businesses),
+	// Count businesses in every feature, or in the single geometry
+	var response interface{}
+	if req.Center == nil && req.Type == "FeatureCollection" {
+		response, err = processFeatures(csvService, req.Features, req.Combined, func(geometry models.GeoJSONGeometry) (interface{}, error) {
+			businesses, err := searchGeometry(ctx, csvService, geometry, req.NAFCodes)
+			if err != nil {
+				return nil, err
+			}
+			return models.CompetitorCountResponse{NumberOfCompetitors: len(businesses)}, nil
+		})
+	} else {
+		var businesses []*models.Business
+		if req.Center != nil {
+			businesses, err = csvService.SearchBusinessesInRadius(ctx, *req.Center, req.Radius, req.NAFCodes)
+		} else {
+			businesses, err = searchGeometry(ctx, csvService, req.Geometry, req.NAFCodes)
+		}
+		if err == nil {
+			response, err = models.CompetitorCountResponse{NumberOfCompetitors: len(businesses)}, nil
+		}
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
 	}
 
 	// Return results
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(numberOfCompetitors); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding response: %v", err)
 		return
 	}
 
@@ -373,73 +503,96 @@ func (h *SearchHandler) HandleCompetitorCount(w http.ResponseWriter, r *http.Req
 	log.Printf("Request processed in %v\n", duration)
 }
 
-// HandleCompetitionData handles the competition data request
+// HandleCompetitionData handles the competition data request. CSV and XLSX formats list
+// every competitor with its financial data instead of the aggregated statistics.
+// The competitor search of a single area is stored as a search result.
 func (h *SearchHandler) HandleCompetitionData(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	format, formatErr := responseFormat(r, formatCSV, formatXLSX)
+	if formatErr != nil {
+		writeRequestError(w, formatErr)
+		return
+	}
+	exportOptions, localeErr := csvOptions(r)
+	if localeErr != nil {
+		writeRequestError(w, localeErr)
 		return
 	}
 
 	var req models.SearchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeGeometryRequest(w, r, &req, &req.GeometryRequest) {
 		return
 	}
 
+	// Stop processing when the client goes away or the endpoint deadline expires
+	ctx, cancel := requestContext(r, config.Timeout(config.GetTimeoutConfig().CompetitionData))
+	defer cancel()
+
 	// Validate request
 	if len(req.NAFCodes) == 0 {
-		http.Error(w, "At least one NAF code is required", http.StatusBadRequest)
+		writeRequestError(w, &apiError{Code: "missing_naf_codes", Message: "At least one NAF code is required"})
 		return
 	}
 
-	// Get geometry based on request type
-	var geometry interface{}
-	if req.Type == "FeatureCollection" {
-		if len(req.Features) == 0 {
-			http.Error(w, "GeoJSON feature is required", http.StatusBadRequest)
-			return
-		}
-		if req.Features[0].Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Features[0].Geometry.Coordinates = simplifyGeoJSONPolygon(req.Features[0].Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Features[0].Geometry
-	} else if req.Type == "Feature" {
-		if req.Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Geometry.Coordinates = simplifyGeoJSONPolygon(req.Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Geometry
-	} else {
-		http.Error(w, "Invalid GeoJSON type. Must be either 'Feature' or 'FeatureCollection'", http.StatusBadRequest)
+	// Requests run on the dataset generation current when they start
+	csvService, err := h.datasets.Service()
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
-
-	// Search for businesses
-	geojsonStr, _ := json.Marshal(geometry)
-	businesses, err := h.csvService.SearchBusinesses(string(geojsonStr), req.NAFCodes, true)
+	area, err := resolveArea(ctx, csvService, &req.GeometryRequest)
 	if err != nil {
-		http.Error(w, "Error processing request", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
-	competitionData, err := h.csvService.GetCompetitionData(businesses)
+	// Get competition data for every feature, or for the single geometry
+	competitionData := func(businesses []*models.Business) (interface{}, error) {
+		if format != formatJSON {
+			return csvService.GetCompetitorDetails(ctx, businesses)
+		}
+		return csvService.GetCompetitionData(ctx, businesses)
+	}
+	var response interface{}
+	if req.Center == nil && req.Type == "FeatureCollection" {
+		response, err = processFeatures(csvService, req.Features, req.Combined, func(geometry models.GeoJSONGeometry) (interface{}, error) {
+			businesses, err := searchGeometry(ctx, csvService, geometry, req.NAFCodes)
+			if err != nil {
+				return nil, err
+			}
+			return competitionData(businesses)
+		})
+	} else {
+		var businesses []*models.Business
+		if req.Center != nil {
+			businesses, err = csvService.SearchBusinessesInRadius(ctx, *req.Center, req.Radius, req.NAFCodes)
+		} else {
+			businesses, err = searchGeometry(ctx, csvService, req.Geometry, req.NAFCodes)
+		}
+		if err == nil {
+			response, err = competitionData(businesses)
+		}
+		if err == nil {
+			response = withArea(response, area)
+			saveResult(w, h.store, results.KindSearch, req, withArea(groupBusinessesByNAF(businesses), area))
+		}
+	}
 	if err != nil {
-		http.Error(w, "Error processing request", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
 	// Return results
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(competitionData); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		return
+	w.Header().Set("Vary", "Accept")
+	if format != formatJSON {
+		writeTable(w, format, exportOptions, competitionTable(response))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			return
+		}
 	}
 
 	// Log processing time
@@ -450,74 +603,135 @@ func (h *SearchHandler) HandleCompetitionData(w http.ResponseWriter, r *http.Req
 
 // IrisHandler handles IRIS data requests
 type IrisHandler struct {
-	csvService *services.CSVService
+	datasets *services.Datasets
+	store    results.Store
 }
 
 // NewIrisHandler creates a new IrisHandler instance
-func NewIrisHandler(csvService *services.CSVService) *IrisHandler {
+func NewIrisHandler(datasets *services.Datasets, store results.Store) *IrisHandler {
 	return &IrisHandler{
-		csvService: csvService,
+		datasets: datasets,
+		store:    store,
+	}
+}
+
+// HandleIrisMetrics lists the statistics and indicators an IRIS data request can select
+func (h *IrisHandler) HandleIrisMetrics(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.IrisMetricsResponse{
+		Statistics: models.IrisStatisticLabels,
+		Indicators: models.IrisIndicators,
+	})
+}
+
+// maxIrisPrecision is the largest number of decimals an IRIS data request can ask for
+const maxIrisPrecision = 6
+
+// irisOptions validates the metrics and precision of an IRIS data request and returns its options
+func irisOptions(req models.IrisRequest) (services.IrisOptions, *apiError) {
+	for _, metric := range req.Metrics {
+		if !models.IsIrisStatistic(metric) && models.IrisIndicatorByKey(metric) == nil {
+			return services.IrisOptions{}, (&apiError{
+				Code:    "unknown_metric",
+				Message: "Metric is neither an IRIS statistic nor an indicator, see /iris-data/metrics",
+			}).withDetail("metric", metric)
+		}
 	}
+	if req.Precision != nil && (*req.Precision < 0 || *req.Precision > maxIrisPrecision) {
+		return services.IrisOptions{}, (&apiError{
+			Code:    "invalid_precision",
+			Message: fmt.Sprintf("Precision must be between 0 and %d", maxIrisPrecision),
+		}).withDetail("precision", *req.Precision)
+	}
+	return services.IrisOptions{
+		Breakdown:         req.Breakdown || req.BreakdownGeometry,
+		BreakdownGeometry: req.BreakdownGeometry,
+		Metrics:           req.Metrics,
+		Precision:         req.Precision,
+	}, nil
 }
 
-// HandleIrisData handles the IRIS data request
+// irisDataForGeometry retrieves IRIS data for a GeoJSON geometry
+func irisDataForGeometry(ctx context.Context, csvService *services.CSVService, geometry models.GeoJSONGeometry, options services.IrisOptions) (*models.IrisResponse, error) {
+	geojsonStr, err := json.Marshal(geometry)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding geometry: %v", err)
+	}
+	return csvService.GetIrisData(ctx, string(geojsonStr), options)
+}
+
+// HandleIrisData handles the IRIS data request. CSV and XLSX formats list one statistic per row.
+// The result is stored in its JSON form.
 func (h *IrisHandler) HandleIrisData(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	format, formatErr := responseFormat(r, formatCSV, formatXLSX)
+	if formatErr != nil {
+		writeRequestError(w, formatErr)
+		return
+	}
+	exportOptions, localeErr := csvOptions(r)
+	if localeErr != nil {
+		writeRequestError(w, localeErr)
 		return
 	}
 
 	var req models.IrisRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request format", http.StatusBadRequest)
+	if !decodeGeometryRequest(w, r, &req, &req.GeometryRequest) {
+		return
+	}
+	options, optionsErr := irisOptions(req)
+	if optionsErr != nil {
+		writeRequestError(w, optionsErr)
 		return
 	}
 
-	// Get geometry based on request type
-	var geometry interface{}
-	if req.Type == "FeatureCollection" {
-		if len(req.Features) == 0 {
-			http.Error(w, "GeoJSON feature is required", http.StatusBadRequest)
-			return
-		}
-		if req.Features[0].Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Features[0].Geometry.Coordinates = simplifyGeoJSONPolygon(req.Features[0].Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Features[0].Geometry
-	} else if req.Type == "Feature" {
-		if req.Geometry.Type != "Polygon" {
-			http.Error(w, "Only Polygon geometry type is supported", http.StatusBadRequest)
-			return
-		}
-		// Simplify the polygon coordinates
-		req.Geometry.Coordinates = simplifyGeoJSONPolygon(req.Geometry.Coordinates) // Adjust epsilon as needed
-		geometry = req.Geometry
-	} else {
-		http.Error(w, "Invalid GeoJSON type. Must be either 'Feature' or 'FeatureCollection'", http.StatusBadRequest)
+	// Stop processing when the client goes away or the endpoint deadline expires
+	ctx, cancel := requestContext(r, config.Timeout(config.GetTimeoutConfig().IrisData))
+	defer cancel()
+
+	// Requests run on the dataset generation current when they start
+	csvService, err := h.datasets.Service()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	area, err := resolveArea(ctx, csvService, &req.GeometryRequest)
+	if err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
-	// Get IRIS data
-	geojsonStr, _ := json.Marshal(geometry)
-	irisData, err := h.csvService.GetIrisData(string(geojsonStr))
+	// Get IRIS data for every feature, or for the single geometry
+	var response interface{}
+	if req.Center == nil && req.Type == "FeatureCollection" {
+		response, err = processFeatures(csvService, req.Features, req.Combined, func(geometry models.GeoJSONGeometry) (interface{}, error) {
+			return irisDataForGeometry(ctx, csvService, geometry, options)
+		})
+	} else if req.Center != nil {
+		response, err = csvService.GetIrisDataInRadius(ctx, *req.Center, req.Radius, options)
+	} else {
+		response, err = irisDataForGeometry(ctx, csvService, req.Geometry, options)
+	}
 	if err != nil {
-		http.Error(w, "Error processing request", http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
+	response = withArea(response, area)
+	saveResult(w, h.store, results.KindIris, req, response)
 
 	// Return results
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(irisData); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
-		return
+	w.Header().Set("Vary", "Accept")
+	if format != formatJSON {
+		writeTable(w, format, exportOptions, irisTable(response))
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			return
+		}
 	}
 
 	// Log processing time
 	duration := time.Since(startTime)
 	log.Printf("Request processed in %v\n", duration)
-} 
\ No newline at end of file
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"csv-processor/internal/services"
+)
+
+// HealthHandler serves the health, readiness and dataset endpoints.
+// It is usable before the datasets are loaded so orchestrators can wait for readiness.
+type HealthHandler struct {
+	datasets *services.Datasets
+}
+
+// NewHealthHandler creates a new HealthHandler instance
+func NewHealthHandler(datasets *services.Datasets) *HealthHandler {
+	return &HealthHandler{
+		datasets: datasets,
+	}
+}
+
+// ReadinessResponse represents the response of the readiness endpoint
+type ReadinessResponse struct {
+	Status           string   `json:"status"`
+	InactiveDatasets []string `json:"inactive_datasets,omitempty"`
+	// OptionalDatasets are the inactive datasets the instance serves requests without
+	OptionalDatasets []string `json:"optional_datasets,omitempty"`
+}
+
+// HandleHealthz reports that the process is alive
+func (h *HealthHandler) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// HandleReadyz reports whether the datasets are loaded and indexed. The instance is ready when the
+// services of the required datasets are active, and degraded but ready when only optional ones are not.
+func (h *HealthHandler) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	csvService := h.datasets.Current()
+	if csvService == nil {
+		writeJSON(w, http.StatusServiceUnavailable, ReadinessResponse{Status: "loading"})
+		return
+	}
+	status, response := readiness(services.DescribeDatasets(csvService))
+	writeJSON(w, status, response)
+}
+
+// readiness returns the readiness status code and response of a loaded generation
+func readiness(datasets []services.DatasetInfo) (int, ReadinessResponse) {
+	response := ReadinessResponse{Status: "ready"}
+	for _, dataset := range datasets {
+		if dataset.ServiceActive {
+			continue
+		}
+		if dataset.Required {
+			response.InactiveDatasets = append(response.InactiveDatasets, dataset.Name)
+		} else {
+			response.OptionalDatasets = append(response.OptionalDatasets, dataset.Name)
+		}
+	}
+	if len(response.InactiveDatasets) > 0 {
+		response.Status = "unavailable"
+		return http.StatusServiceUnavailable, response
+	}
+	if len(response.OptionalDatasets) > 0 {
+		response.Status = "degraded"
+	}
+	return http.StatusOK, response
+}
+
+// HandleDatasets lists the datasets of the current generation with their file and load information,
+// along with the state of the last reload
+func (h *HealthHandler) HandleDatasets(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"datasets": services.DescribeDatasets(h.datasets.Current()),
+		"reload":   h.datasets.Status(),
+	})
+}
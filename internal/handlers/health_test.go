@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"csv-processor/internal/services"
+)
+
+func TestReadiness(t *testing.T) {
+	datasets := func(inactive ...string) []services.DatasetInfo {
+		infos := []services.DatasetInfo{
+			{Name: services.DatasetBusinesses, Required: true, ServiceActive: true},
+			{Name: services.DatasetIris, Required: true, ServiceActive: true},
+			{Name: services.DatasetCommuneCrimes, ServiceActive: true},
+			{Name: services.DatasetDepartmentCrimes, ServiceActive: true},
+		}
+		for i := range infos {
+			for _, name := range inactive {
+				if infos[i].Name == name {
+					infos[i].ServiceActive = false
+				}
+			}
+		}
+		return infos
+	}
+
+	tests := []struct {
+		name     string
+		datasets []services.DatasetInfo
+		status   int
+		response ReadinessResponse
+	}{
+		{"all active", datasets(), http.StatusOK, ReadinessResponse{Status: "ready"}},
+		{
+			"optional crime files missing",
+			datasets(services.DatasetCommuneCrimes, services.DatasetDepartmentCrimes),
+			http.StatusOK,
+			ReadinessResponse{Status: "degraded", OptionalDatasets: []string{services.DatasetCommuneCrimes, services.DatasetDepartmentCrimes}},
+		},
+		{
+			"required dataset missing",
+			datasets(services.DatasetIris, services.DatasetCommuneCrimes),
+			http.StatusServiceUnavailable,
+			ReadinessResponse{Status: "unavailable", InactiveDatasets: []string{services.DatasetIris}, OptionalDatasets: []string{services.DatasetCommuneCrimes}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := readiness(tt.datasets)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if !reflect.DeepEqual(response, tt.response) {
+				t.Errorf("response = %+v, want %+v", response, tt.response)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"csv-processor/internal/metrics"
+)
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// recordStatus changes the status recorded for a request whose status was already sent,
+// such as a stream canceled after its first line
+func recordStatus(w http.ResponseWriter, status int) {
+	if recorder, ok := w.(*statusRecorder); ok {
+		recorder.status = status
+	}
+}
+
+// Flush lets streaming handlers flush through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Instrument wraps a handler to count its requests by status code and observe its latency
+func Instrument(endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(recorder, r)
+
+		metrics.RequestsTotal.Inc(endpoint, strconv.Itoa(recorder.status))
+		metrics.RequestDuration.Observe(time.Since(startTime).Seconds(), endpoint)
+	}
+}
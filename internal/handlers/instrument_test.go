@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"csv-processor/internal/services"
+)
+
+func TestCanceledRequestStatus(t *testing.T) {
+	canceled := &services.ServiceError{Kind: services.ErrorCanceled, Message: "Request canceled", Err: context.Canceled}
+
+	t.Run("before the response", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		recorder := &statusRecorder{ResponseWriter: response, status: http.StatusOK}
+		writeServiceError(recorder, canceled)
+
+		if recorder.status != statusClientClosedRequest {
+			t.Errorf("recorded status = %d, want %d", recorder.status, statusClientClosedRequest)
+		}
+		if response.Body.Len() != 0 {
+			t.Errorf("body = %q, want none", response.Body.String())
+		}
+	})
+
+	t.Run("during a stream", func(t *testing.T) {
+		recorder := &statusRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+		stream := newNDJSONStream(recorder)
+		if err := stream.writeLine(map[string]string{"type": "business"}); err != nil {
+			t.Fatalf("writeLine() error = %v", err)
+		}
+		stream.fail(canceled)
+
+		if recorder.status != statusClientClosedRequest {
+			t.Errorf("recorded status = %d, want %d", recorder.status, statusClientClosedRequest)
+		}
+	})
+}
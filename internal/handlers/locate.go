@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/models"
+)
+
+// HandleLocate returns the IRIS zone, commune, department and QP zones containing the point
+// given by the lat and lng query parameters
+func (h *IrisHandler) HandleLocate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var point models.Point
+	for _, coordinate := range []struct {
+		name  string
+		value *float64
+	}{{"lat", &point.Lat}, {"lng", &point.Lng}} {
+		value, err := strconv.ParseFloat(query.Get(coordinate.name), 64)
+		if err != nil {
+			writeRequestError(w, (&apiError{
+				Code:    "invalid_coordinate",
+				Message: "Query parameters lat and lng must be numbers",
+			}).withDetail(coordinate.name, query.Get(coordinate.name)))
+			return
+		}
+		*coordinate.value = value
+	}
+	if !config.GetBounds().Contains(point.Lng, point.Lat) {
+		writeRequestError(w, (&apiError{
+			Code:    "out_of_bounds",
+			Message: "Point is outside of France",
+		}).withDetail("coordinate", []float64{point.Lng, point.Lat}))
+		return
+	}
+
+	// Requests run on the dataset generation current when they start
+	csvService, err := h.datasets.Service()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, csvService.Locate(point))
+}
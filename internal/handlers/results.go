@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"csv-processor/internal/metrics"
+	"csv-processor/internal/models"
+	"csv-processor/internal/results"
+)
+
+// resultIDHeader is the response header carrying the ID of the stored result
+const resultIDHeader = "X-Result-ID"
+
+// Number of results listed by default and at most
+const (
+	defaultResultsLimit = 100
+	maxResultsLimit     = 1000
+)
+
+// saveResult stores the result of a request along with the request, and sends its ID in the
+// resultIDHeader header. A failure to store the result does not fail the request.
+func saveResult(w http.ResponseWriter, store results.Store, kind string, request, result interface{}) {
+	id, err := store.Save(kind, request, result)
+	if err != nil {
+		log.Printf("Warning: error storing %s result: %v", kind, err)
+		metrics.ResultsWriteFailures.Inc(kind)
+		return
+	}
+	if id != "" {
+		w.Header().Set(resultIDHeader, id)
+	}
+}
+
+// groupSearchResponse builds the JSON response of a search from its business lists,
+// grouping the businesses of every area by NAF code
+func groupSearchResponse(response interface{}) interface{} {
+	switch response := response.(type) {
+	case []*models.Business:
+		return groupBusinessesByNAF(response)
+	case *models.FeatureCollectionResponse:
+		grouped := &models.FeatureCollectionResponse{
+			Features: make([]models.FeatureResult, len(response.Features)),
+		}
+		for i, feature := range response.Features {
+			feature.Result = groupSearchResponse(feature.Result)
+			grouped.Features[i] = feature
+		}
+		if response.Combined != nil {
+			grouped.Combined = groupSearchResponse(response.Combined)
+		}
+		return grouped
+	}
+	return response
+}
+
+// ResultsHandler serves the stored results
+type ResultsHandler struct {
+	store results.Store
+}
+
+// NewResultsHandler creates a new ResultsHandler instance
+func NewResultsHandler(store results.Store) *ResultsHandler {
+	return &ResultsHandler{
+		store: store,
+	}
+}
+
+// HandleGetResult returns a stored result with the request it answered
+func (h *ResultsHandler) HandleGetResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	record, err := h.store.Get(id)
+	if errors.Is(err, results.ErrNotFound) {
+		writeError(w, http.StatusNotFound, (&apiError{Code: "not_found", Message: "Result not found"}).withDetail("id", id))
+		return
+	}
+	if err != nil {
+		log.Printf("Error reading result %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, &apiError{Code: "internal_error", Message: "Error reading result"})
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// HandleListResults lists the stored results, most recent first, without their content.
+// The type parameter selects a kind of result, since the results created from a date or time
+// (RFC 3339), and limit their number.
+func (h *ResultsHandler) HandleListResults(w http.ResponseWriter, r *http.Request) {
+	filter, filterErr := resultsFilter(r)
+	if filterErr != nil {
+		writeRequestError(w, filterErr)
+		return
+	}
+
+	records, err := h.store.List(filter)
+	if err != nil {
+		log.Printf("Error listing results: %v", err)
+		writeError(w, http.StatusInternalServerError, &apiError{Code: "internal_error", Message: "Error listing results"})
+		return
+	}
+	if records == nil {
+		records = []*results.Record{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": records})
+}
+
+// resultsFilter reads the filter of a results listing from the query parameters
+func resultsFilter(r *http.Request) (results.Filter, *apiError) {
+	query := r.URL.Query()
+	filter := results.Filter{Kind: query.Get("type"), Limit: defaultResultsLimit}
+
+	if filter.Kind != "" && filter.Kind != results.KindSearch && filter.Kind != results.KindIris {
+		return filter, (&apiError{
+			Code:    "invalid_type",
+			Message: "Type must be search or iris",
+		}).withDetail("type", filter.Kind)
+	}
+
+	if since := query.Get("since"); since != "" {
+		var err error
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			if filter.Since, err = time.Parse(time.DateOnly, since); err != nil {
+				return filter, (&apiError{
+					Code:    "invalid_since",
+					Message: "Since must be a date (2006-01-02) or an RFC 3339 time",
+				}).withDetail("since", since)
+			}
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 || filter.Limit > maxResultsLimit {
+			return filter, (&apiError{
+				Code:    "invalid_limit",
+				Message: "Limit must be between 1 and " + strconv.Itoa(maxResultsLimit),
+			}).withDetail("limit", limit)
+		}
+	}
+	return filter, nil
+}
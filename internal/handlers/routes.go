@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// Route registers handler on mux for the given method and path, instrumented under endpoint.
+// Other methods on the same path get a JSON 405 response listing the allowed method.
+func Route(mux *http.ServeMux, method, path, endpoint string, handler http.HandlerFunc) {
+	mux.HandleFunc(method+" "+path, Instrument(endpoint, handler))
+	mux.HandleFunc(path, Instrument(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allowedMethods(method))
+		writeMethodNotAllowed(w)
+	}))
+}
+
+// allowedMethods returns the Allow header value of a route, GET routes also answering HEAD
+func allowedMethods(method string) string {
+	if method == http.MethodGet {
+		return "GET, HEAD"
+	}
+	return method
+}
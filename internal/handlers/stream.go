@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"csv-processor/internal/models"
+	"csv-processor/internal/services"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON responses
+const ndjsonContentType = "application/x-ndjson"
+
+// ndjsonFlushInterval is the number of lines written between two flushes of a stream
+const ndjsonFlushInterval = 1000
+
+// ndjsonStream writes a search response as newline-delimited JSON: one line per business,
+// then a summary line, or an error line when the search fails after the first line was sent
+type ndjsonStream struct {
+	w        http.ResponseWriter
+	encoder  *json.Encoder
+	started  bool
+	lines    int
+	counts   map[string]int
+	total    int
+	writeErr error
+}
+
+// newNDJSONStream creates a stream writing to w
+func newNDJSONStream(w http.ResponseWriter) *ndjsonStream {
+	return &ndjsonStream{
+		w:       w,
+		encoder: json.NewEncoder(w),
+		counts:  make(map[string]int),
+	}
+}
+
+// writeLine writes one line, sending the headers before the first one
+func (s *ndjsonStream) writeLine(line interface{}) error {
+	if !s.started {
+		s.w.Header().Set("Content-Type", ndjsonContentType)
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	if err := s.encoder.Encode(line); err != nil {
+		s.writeErr = err
+		return err
+	}
+	s.lines++
+	if s.lines%ndjsonFlushInterval == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// flush sends the buffered lines to the client
+func (s *ndjsonStream) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// emitter returns the function writing the businesses found in a request feature, or in the
+// whole request area when feature is nil
+func (s *ndjsonStream) emitter(feature *int) func(business *models.Business) error {
+	return func(business *models.Business) error {
+		s.counts[business.NAFCode]++
+		s.total++
+		return s.writeLine(models.StreamedBusiness{Type: "business", Feature: feature, Business: business})
+	}
+}
+
+// summary writes the summary line with the number of businesses per NAF code
+func (s *ndjsonStream) summary() {
+	summary := models.StreamSummary{
+		Type:               "summary",
+		NumberOfBusinesses: s.total,
+		NAFCodes:           make([]models.NAFCodeCount, 0, len(s.counts)),
+	}
+	for code, count := range s.counts {
+		summary.NAFCodes = append(summary.NAFCodes, models.NAFCodeCount{NAFCode: code, NumberOfBusinesses: count})
+	}
+	sort.Slice(summary.NAFCodes, func(i, j int) bool {
+		return summary.NAFCodes[i].NAFCode < summary.NAFCodes[j].NAFCode
+	})
+
+	if err := s.writeLine(summary); err != nil {
+		log.Printf("Error writing stream: %v", err)
+		return
+	}
+	s.flush()
+}
+
+// fail reports an error, as an error response when nothing was sent yet or as the last line otherwise
+func (s *ndjsonStream) fail(err error) {
+	if s.writeErr != nil {
+		log.Printf("Error writing stream: %v", s.writeErr)
+		return
+	}
+	if !s.started {
+		writeServiceError(s.w, err)
+		return
+	}
+
+	// The status was sent with the first line, so the error goes in the body
+	line := &apiError{Code: string(services.ErrorInternal), Message: "Error processing request"}
+	var serviceErr *services.ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.Kind == services.ErrorCanceled {
+			log.Printf("Request canceled: %v", err)
+			recordStatus(s.w, statusClientClosedRequest)
+			return
+		}
+		line = &apiError{Code: string(serviceErr.Kind), Message: serviceErr.Message, Details: serviceErr.Details}
+	}
+	log.Printf("Error streaming search: %v", err)
+
+	if err := s.writeLine(struct {
+		Type string `json:"type"`
+		*apiError
+	}{"error", line}); err != nil {
+		log.Printf("Error writing stream: %v", err)
+		return
+	}
+	s.flush()
+}
+
+// streamGeometry streams the businesses found within a GeoJSON geometry
+func streamGeometry(ctx context.Context, csvService *services.CSVService, geometry models.GeoJSONGeometry, nafCodes []string, emit func(business *models.Business) error) error {
+	geojsonStr, err := json.Marshal(geometry)
+	if err != nil {
+		return fmt.Errorf("error encoding geometry: %v", err)
+	}
+	_, err = csvService.StreamBusinesses(ctx, string(geojsonStr), nafCodes, emit)
+	return err
+}
+
+// streamSearch writes the businesses of a search as newline-delimited JSON while the scan runs,
+// so that neither the server nor the client hold the whole result. Businesses of a FeatureCollection
+// carry the index of their feature, unless the features are combined.
+func streamSearch(ctx context.Context, w http.ResponseWriter, csvService *services.CSVService, req models.SearchRequest) {
+	stream := newNDJSONStream(w)
+
+	var err error
+	switch {
+	case req.Center != nil:
+		_, err = csvService.StreamBusinessesInRadius(ctx, *req.Center, req.Radius, req.NAFCodes, stream.emitter(nil))
+	case req.Type == "FeatureCollection" && req.Combined:
+		geometries := make([]models.GeoJSONGeometry, 0, len(req.Features))
+		for _, feature := range req.Features {
+			geometries = append(geometries, feature.Geometry)
+		}
+		var union *models.GeoJSONGeometry
+		if union, err = csvService.UnionGeoJSON(geometries); err != nil {
+			err = fmt.Errorf("error merging features: %w", err)
+		} else {
+			err = streamGeometry(ctx, csvService, *union, req.NAFCodes, stream.emitter(nil))
+		}
+	case req.Type == "FeatureCollection":
+		for i, feature := range req.Features {
+			index := i
+			if err = streamGeometry(ctx, csvService, feature.Geometry, req.NAFCodes, stream.emitter(&index)); err != nil {
+				// Tell the client which feature failed
+				var serviceErr *services.ServiceError
+				if errors.As(err, &serviceErr) {
+					serviceErr.WithDetail("feature", i)
+				}
+				err = fmt.Errorf("error processing feature %d: %w", i, err)
+				break
+			}
+		}
+	default:
+		err = streamGeometry(ctx, csvService, req.Geometry, req.NAFCodes, stream.emitter(nil))
+	}
+
+	if err != nil {
+		stream.fail(err)
+		return
+	}
+	stream.summary()
+}
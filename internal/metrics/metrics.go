@@ -0,0 +1,323 @@
+// Package metrics implements the few Prometheus metric types the service needs
+// and exposes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric is a metric family that can write itself in the exposition format
+type metric interface {
+	name() string
+	write(buf *bytes.Buffer)
+}
+
+// registry holds every metric created by this package
+var registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// register adds a metric to the registry
+func register(m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.metrics = append(registry.metrics, m)
+}
+
+// series is the value of a metric for one combination of label values
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// vector stores the series of a metric family by label values
+type vector struct {
+	metricName string
+	help       string
+	labelNames []string
+	mu         sync.Mutex
+	series     map[string]*series
+}
+
+func newVector(name, help string, labelNames []string) vector {
+	return vector{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+}
+
+func (v *vector) name() string {
+	return v.metricName
+}
+
+// get returns the series of the given label values, creating it if needed. v.mu must be held.
+func (v *vector) get(labelValues []string) *series {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", v.metricName, len(v.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, exists := v.series[key]
+	if !exists {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sortedSeries returns the series ordered by label values. v.mu must be held.
+func (v *vector) sortedSeries() []*series {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*series, len(keys))
+	for i, key := range keys {
+		sorted[i] = v.series[key]
+	}
+	return sorted
+}
+
+// writeHeader writes the HELP and TYPE lines of the family
+func (v *vector) writeHeader(buf *bytes.Buffer, metricType string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", v.metricName, escapeHelp(v.help))
+	fmt.Fprintf(buf, "# TYPE %s %s\n", v.metricName, metricType)
+}
+
+// writeSample writes one sample line with the given labels and an optional extra label
+func writeSample(buf *bytes.Buffer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	buf.WriteString(name)
+	if len(labelNames) > 0 || extraName != "" {
+		buf.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%s=\"%s\"", labelName, escapeLabelValue(labelValues[i]))
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(buf, "%s=\"%s\"", extraName, extraValue)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+// Counter is a monotonically increasing value, optionally split by labels
+type Counter struct {
+	vector
+}
+
+// NewCounter creates and registers a counter
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{vector: newVector(name, help, labelNames)}
+	register(c)
+	return c
+}
+
+// Inc adds one to the series of the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative value to the series of the given label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues).value += value
+}
+
+func (c *Counter) write(buf *bytes.Buffer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(buf, "counter")
+	if len(c.labelNames) == 0 && len(c.series) == 0 {
+		writeSample(buf, c.metricName, nil, nil, "", "", 0)
+	}
+	for _, s := range c.sortedSeries() {
+		writeSample(buf, c.metricName, c.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+// Gauge is a value that can go up and down, optionally split by labels
+type Gauge struct {
+	vector
+}
+
+// NewGauge creates and registers a gauge
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{vector: newVector(name, help, labelNames)}
+	register(g)
+	return g
+}
+
+// Set sets the series of the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value = value
+}
+
+func (g *Gauge) write(buf *bytes.Buffer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(buf, "gauge")
+	for _, s := range g.sortedSeries() {
+		writeSample(buf, g.metricName, g.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+// GaugeFunc is a gauge whose value is read when the metrics are scraped
+type GaugeFunc struct {
+	vector
+	value func() float64
+}
+
+// NewGaugeFunc creates and registers a gauge reading its value from the given function
+func NewGaugeFunc(name, help string, value func() float64) *GaugeFunc {
+	g := &GaugeFunc{vector: newVector(name, help, nil), value: value}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(buf *bytes.Buffer) {
+	g.writeHeader(buf, "gauge")
+	writeSample(buf, g.metricName, nil, nil, "", "", g.value())
+}
+
+// histogramSeries holds the bucket counts of one combination of label values
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// Histogram counts observations in buckets, optionally split by labels
+type Histogram struct {
+	vector
+	buckets    []float64
+	histograms map[string]*histogramSeries
+}
+
+// DefaultBuckets are latency buckets in seconds, from 5ms to 2 minutes
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// NewHistogram creates and registers a histogram with the given upper bounds
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	h := &Histogram{
+		vector:     newVector(name, help, labelNames),
+		buckets:    append([]float64(nil), buckets...),
+		histograms: make(map[string]*histogramSeries),
+	}
+	sort.Float64s(h.buckets)
+	register(h)
+	return h
+}
+
+// Observe records a value in the series of the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Track the label values in the vector so series are listed in order
+	key := strings.Join(h.get(labelValues).labelValues, "\xff")
+	hs, exists := h.histograms[key]
+	if !exists {
+		hs = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.histograms[key] = hs
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		hs.counts[i]++
+	}
+	hs.count++
+	hs.sum += value
+}
+
+func (h *Histogram) write(buf *bytes.Buffer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(buf, "histogram")
+	for _, s := range h.sortedSeries() {
+		hs := h.histograms[strings.Join(s.labelValues, "\xff")]
+		cumulative := uint64(0)
+		for i, bound := range h.buckets {
+			cumulative += hs.counts[i]
+			writeSample(buf, h.metricName+"_bucket", h.labelNames, hs.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(buf, h.metricName+"_bucket", h.labelNames, hs.labelValues, "le", "+Inf", float64(hs.count))
+		writeSample(buf, h.metricName+"_sum", h.labelNames, hs.labelValues, "", "", hs.sum)
+		writeSample(buf, h.metricName+"_count", h.labelNames, hs.labelValues, "", "", float64(hs.count))
+	}
+}
+
+// Handler serves every registered metric in the Prometheus text exposition format
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		metrics := append([]metric(nil), registry.metrics...)
+		registry.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(expose(metrics))
+	})
+}
+
+// expose writes the metric families ordered by name
+func expose(metrics []metric) []byte {
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name() < metrics[j].name()
+	})
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.write(&buf)
+	}
+	return buf.Bytes()
+}
+
+// formatFloat formats a sample value as Prometheus expects
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeHelp escapes a HELP text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabelValue escapes a label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// checkGolden compares got with a file of testdata, rewriting the file with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("exposition differs from %s:\n%s", path, got)
+	}
+}
+
+func TestExposition(t *testing.T) {
+	// Families are built without registering them, so the output only holds these
+	requests := &Counter{vector: newVector("test_requests_total", "Requests by endpoint and code.", []string{"endpoint", "code"})}
+	requests.Inc("/search", "200")
+	requests.Add(2, "/search", "200")
+	requests.Inc("/iris-data", "504")
+	requests.Inc("/quote\"back\\slash\nnewline", "400")
+	// Counters never decrease
+	requests.Add(-1, "/search", "200")
+
+	idle := &Counter{vector: newVector("test_idle_total", "Counter without labels nor samples.", nil)}
+	empty := &Gauge{vector: newVector("test_empty", "Gauge with labels but no series.", []string{"dataset"})}
+
+	rows := &Gauge{vector: newVector("test_rows", "Rows by dataset.", []string{"dataset"})}
+	rows.Set(1200000, "iris_data")
+	rows.Set(0.25, "qp_data")
+	rows.Set(math.Inf(1), "business_data")
+	rows.Set(math.NaN(), "communes")
+	rows.Set(math.Inf(-1), "competition")
+
+	goroutines := &GaugeFunc{vector: newVector("test_goroutines", "Help with a \\ backslash\nand a newline.", nil), value: func() float64 { return 7 }}
+
+	latency := &Histogram{
+		vector:     newVector("test_duration_seconds", "Latency by endpoint.", []string{"endpoint"}),
+		buckets:    []float64{0.1, 0.5, 1},
+		histograms: make(map[string]*histogramSeries),
+	}
+	// A value equal to a bound falls in its bucket, one above every bound only in +Inf
+	for _, value := range []float64{0.05, 0.1, 0.7, 3} {
+		latency.Observe(value, "/search")
+	}
+	latency.Observe(0.3, "/iris-data")
+
+	checkGolden(t, "exposition.golden", expose([]metric{rows, requests, latency, idle, goroutines, empty}))
+}
+
+func TestHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+
+	// Every registered family is exposed once, in name order
+	var families []string
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if name, found := strings.CutPrefix(line, "# TYPE "); found {
+			families = append(families, strings.Fields(name)[0])
+		}
+	}
+	if len(families) != len(registry.metrics) {
+		t.Errorf("exposed %d families, want %d", len(families), len(registry.metrics))
+	}
+	if !sort.StringsAreSorted(families) {
+		t.Errorf("families are not sorted: %v", families)
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import "runtime"
+
+// Metrics of the HTTP handlers
+var (
+	RequestsTotal = NewCounter("csv_processor_http_requests_total",
+		"HTTP requests by endpoint and status code.", "endpoint", "code")
+	RequestDuration = NewHistogram("csv_processor_http_request_duration_seconds",
+		"HTTP request latency by endpoint.", DefaultBuckets, "endpoint")
+)
+
+// Metrics of the services
+var (
+	BusinessesScanned = NewCounter("csv_processor_businesses_scanned_total",
+		"Businesses tested against a request area.")
+	BusinessesMatched = NewCounter("csv_processor_businesses_matched_total",
+		"Businesses found inside a request area.")
+	ZonesScanned = NewCounter("csv_processor_zones_scanned_total",
+		"Zones tested for intersection with a request area, by layer.", "layer")
+	ZonesMatched = NewCounter("csv_processor_zones_matched_total",
+		"Zones intersecting a request area, by layer.", "layer")
+	CSVLoadDuration = NewGauge("csv_processor_csv_load_duration_seconds",
+		"Duration of the last load of each CSV dataset.", "dataset")
+	ResultsWriteFailures = NewCounter("csv_processor_results_write_failures_total",
+		"Failed writes to the result store, by kind of result.", "kind")
+	DatasetRows = NewGauge("csv_processor_dataset_rows",
+		"Rows held in memory for each dataset.", "dataset")
+	DatasetBytes = NewGauge("csv_processor_dataset_bytes",
+		"Approximate memory used by each in-memory dataset.", "dataset")
+	DatasetGeneration = NewGauge("csv_processor_dataset_generation",
+		"Generation of the datasets serving requests.")
+	DatasetReloads = NewCounter("csv_processor_dataset_reloads_total",
+		"Dataset reloads by trigger and result.", "trigger", "result")
+)
+
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() float64 {
+		return float64(runtime.NumGoroutine())
+	})
+}
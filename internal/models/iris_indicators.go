@@ -0,0 +1,113 @@
+package models
+
+import (
+	"strings"
+)
+
+// IrisIndicator is a statistic derived from the IRIS data: the sum of the Numerator keys divided
+// by the sum of the Denominator keys, times Scale. Keys prefixed with "-" are subtracted.
+// Indicators are computed from the weighted sums of an area, never by averaging zone values.
+type IrisIndicator struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Unit        string   `json:"unit"`
+	Numerator   []string `json:"numerator"`
+	Denominator []string `json:"denominator"`
+	Scale       float64  `json:"scale"`
+	Precision   int      `json:"precision"` // decimals used when the request sets none
+}
+
+// Units of the IRIS indicators
+const (
+	UnitPercent = "%"
+	UnitRatio   = "ratio"
+)
+
+// ageFifteenAndOver sums the socio-professional categories, which cover the population aged 15 and over
+var ageFifteenAndOver = []string{
+	"employees_category_1", "employees_category_2", "employees_category_3", "employees_category_4",
+	"employees_category_5", "employees_category_6", "employees_category_7", "employees_category_8",
+}
+
+// movedHouseholds sums the households by length of residence
+var movedHouseholds = []string{
+	"housing_moved_since_0_2_years", "housing_moved_since_2_4_years",
+	"housing_moved_since_5_9_years", "housing_moved_since_10p_years",
+}
+
+// share returns a percentage indicator
+func share(key, label string, numerator []string, denominator ...string) IrisIndicator {
+	return IrisIndicator{Key: key, Label: label, Unit: UnitPercent, Numerator: numerator, Denominator: denominator, Scale: 100, Precision: 1}
+}
+
+// IrisIndicators lists the indicators that can be requested along with the IRIS statistics
+var IrisIndicators = []IrisIndicator{
+	share("share_age_0019", "Population aged under 20 (%)", []string{"population_total_age_0019"}, "population_total"),
+	share("share_age_2064", "Population aged 20 to 64 (%)", []string{"population_total_age_2064"}, "population_total"),
+	share("share_age_65p", "Population aged 65 and over (%)", []string{"population_total_age_65P"}, "population_total"),
+	share("share_age_75p", "Population aged 75 and over (%)", []string{"population_total_age_75P"}, "population_total"),
+	share("share_female", "Women (%)", []string{"population_female"}, "population_total"),
+	share("share_foreign", "Foreigners (%)", []string{"population_foreign"}, "population_total"),
+	share("share_immigrant", "Immigrants (%)", []string{"population_immigrant"}, "population_total"),
+	share("share_managers", "Managers and higher intellectual professions among people aged 15 and over (%)", []string{"employees_category_3"}, ageFifteenAndOver...),
+	share("share_workers", "Manual workers among people aged 15 and over (%)", []string{"employees_category_6"}, ageFifteenAndOver...),
+	share("share_retirees", "Retirees among people aged 15 and over (%)", []string{"employees_category_7"}, ageFifteenAndOver...),
+	share("share_single_parent_families", "Single-parent families (%)", []string{"families_monoparental"}, "families_only_number"),
+	share("share_one_person_households", "One-person households (%)", []string{"families_one_person"}, "families_number"),
+	share("share_main_residences", "Main residences among dwellings (%)", []string{"housing_primary_residence"}, "housing_total"),
+	share("share_secondary_residences", "Secondary residences among dwellings (%)", []string{"housing_secondary_residence"}, "housing_total"),
+	share("share_vacant_dwellings", "Vacant dwellings (%)", []string{"housing_empty_residence"}, "housing_total"),
+	share("share_houses", "Houses among dwellings (%)", []string{"housing_houses"}, "housing_total"),
+	share("share_apartments", "Apartments among dwellings (%)", []string{"housing_apartments"}, "housing_total"),
+	share("share_owners", "Owner-occupied main residences (%)", []string{"housing_owners"}, "housing_primary_residence"),
+	share("share_renters", "Rented main residences (%)", []string{"housing_renters"}, "housing_primary_residence"),
+	share("share_households_without_car", "Households with no car (%)", []string{"housing_primary_residence", "-housing_with_atleast_1_cars"}, "housing_primary_residence"),
+	share("share_households_with_2p_cars", "Households with two or more cars (%)", []string{"housing_with_2p_cars"}, "housing_primary_residence"),
+	share("share_moved_less_than_2_years", "Households moved in less than 2 years ago (%)", []string{"housing_moved_since_0_2_years"}, movedHouseholds...),
+	{
+		Key:         "people_per_dwelling",
+		Label:       "People per main residence",
+		Unit:        UnitRatio,
+		Numerator:   []string{"housing_people_per_home"},
+		Denominator: []string{"housing_primary_residence"},
+		Scale:       1,
+		Precision:   2,
+	},
+}
+
+// irisIndicatorsByKey indexes IrisIndicators by key
+var irisIndicatorsByKey = func() map[string]*IrisIndicator {
+	indicators := make(map[string]*IrisIndicator, len(IrisIndicators))
+	for i := range IrisIndicators {
+		indicators[IrisIndicators[i].Key] = &IrisIndicators[i]
+	}
+	return indicators
+}()
+
+// IrisIndicatorByKey returns the indicator of a key, or nil when the key is not an indicator
+func IrisIndicatorByKey(key string) *IrisIndicator {
+	return irisIndicatorsByKey[key]
+}
+
+// Compute returns the value of the indicator over the given statistics. It returns false when the
+// denominator is zero, as for an area without dwellings.
+func (i *IrisIndicator) Compute(values map[string]float64) (float64, bool) {
+	denominator := sumTerms(values, i.Denominator)
+	if denominator == 0 {
+		return 0, false
+	}
+	return sumTerms(values, i.Numerator) / denominator * i.Scale, true
+}
+
+// sumTerms sums the values of the given keys, subtracting those prefixed with "-"
+func sumTerms(values map[string]float64, keys []string) float64 {
+	sum := 0.0
+	for _, key := range keys {
+		if subtracted, found := strings.CutPrefix(key, "-"); found {
+			sum -= values[subtracted]
+		} else {
+			sum += values[key]
+		}
+	}
+	return sum
+}
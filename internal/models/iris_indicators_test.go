@@ -0,0 +1,61 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIrisIndicatorCompute(t *testing.T) {
+	values := map[string]float64{
+		"population_total":            200,
+		"population_female":           104,
+		"housing_primary_residence":   80,
+		"housing_with_atleast_1_cars": 60,
+		"employees_category_3":        30,
+		"employees_category_5":        90,
+	}
+	tests := []struct {
+		key     string
+		want    float64
+		defined bool
+	}{
+		{"share_female", 52, true},
+		// Subtracted keys: households without car are the main residences minus those with cars
+		{"share_households_without_car", 25, true},
+		// The denominator sums the eight socio-professional categories
+		{"share_managers", 25, true},
+		{"share_vacant_dwellings", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			indicator := IrisIndicatorByKey(tt.key)
+			if indicator == nil {
+				t.Fatalf("IrisIndicatorByKey(%q) = nil", tt.key)
+			}
+			got, defined := indicator.Compute(values)
+			if defined != tt.defined || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Compute() = %v, %v, want %v, %v", got, defined, tt.want, tt.defined)
+			}
+		})
+	}
+
+	if indicator := IrisIndicatorByKey("population_total"); indicator != nil {
+		t.Errorf("IrisIndicatorByKey(population_total) = %+v, want nil for a statistic", indicator)
+	}
+}
+
+func TestIrisIndicatorsDefinition(t *testing.T) {
+	keys := make(map[string]bool, len(IrisIndicators))
+	for _, indicator := range IrisIndicators {
+		if keys[indicator.Key] {
+			t.Errorf("indicator %s is defined twice", indicator.Key)
+		}
+		keys[indicator.Key] = true
+		if len(indicator.Numerator) == 0 || len(indicator.Denominator) == 0 || indicator.Scale == 0 {
+			t.Errorf("indicator %s has no numerator, denominator or scale", indicator.Key)
+		}
+		if indicator.Precision < 0 {
+			t.Errorf("indicator %s has a negative precision", indicator.Key)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"strings"
+)
+
+// StatisticLabel is the readable label of a statistic key of the IRIS data
+type StatisticLabel struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// IrisStatisticLabels lists the statistic keys of the IRIS data in the order of the CSV columns,
+// with their readable labels
+var IrisStatisticLabels = []StatisticLabel{
+	{"population_total", "Total population"},
+	{"population_general_age_0002", "Population aged 0 to 2"},
+	{"population_general_age_0305", "Population aged 3 to 5"},
+	{"population_general_age_0610", "Population aged 6 to 10"},
+	{"population_general_age_1117", "Population aged 11 to 17"},
+	{"population_general_age_1824", "Population aged 18 to 24"},
+	{"population_general_age_2539", "Population aged 25 to 39"},
+	{"population_general_age_4054", "Population aged 40 to 54"},
+	{"population_general_age_5564", "Population aged 55 to 64"},
+	{"population_general_age_6579", "Population aged 65 to 79"},
+	{"population_general_age_80P", "Population aged 80 and over"},
+	{"population_total_age_0014", "Population aged 0 to 14"},
+	{"population_total_age_1529", "Population aged 15 to 29"},
+	{"population_total_age_3044", "Population aged 30 to 44"},
+	{"population_total_age_4559", "Population aged 45 to 59"},
+	{"population_total_age_6074", "Population aged 60 to 74"},
+	{"population_total_age_75P", "Population aged 75 and over"},
+	{"population_total_age_0019", "Population aged 0 to 19"},
+	{"population_total_age_2064", "Population aged 20 to 64"},
+	{"population_total_age_65P", "Population aged 65 and over"},
+	{"population_male", "Male population"},
+	{"population_male_age_0014", "Male population aged 0 to 14"},
+	{"population_male_age_1529", "Male population aged 15 to 29"},
+	{"population_male_age_3044", "Male population aged 30 to 44"},
+	{"population_male_age_4559", "Male population aged 45 to 59"},
+	{"population_male_age_6074", "Male population aged 60 to 74"},
+	{"population_male_age_75P", "Male population aged 75 and over"},
+	{"population_male_age_0019", "Male population aged 0 to 19"},
+	{"population_male_age_2064", "Male population aged 20 to 64"},
+	{"population_male_age_65P", "Male population aged 65 and over"},
+	{"population_female", "Female population"},
+	{"population_female_age_0014", "Female population aged 0 to 14"},
+	{"population_female_age_1529", "Female population aged 15 to 29"},
+	{"population_female_age_3044", "Female population aged 30 to 44"},
+	{"population_female_age_4559", "Female population aged 45 to 59"},
+	{"population_female_age_6074", "Female population aged 60 to 74"},
+	{"population_female_age_75P", "Female population aged 75 and over"},
+	{"population_female_age_0019", "Female population aged 0 to 19"},
+	{"population_female_age_2064", "Female population aged 20 to 64"},
+	{"population_female_age_65P", "Female population aged 65 and over"},
+	{"employees_category_1", "Population aged 15 and over: farmers"},
+	{"employees_category_2", "Population aged 15 and over: craftsmen, shopkeepers and business owners"},
+	{"employees_category_3", "Population aged 15 and over: managers and higher intellectual professions"},
+	{"employees_category_4", "Population aged 15 and over: intermediate professions"},
+	{"employees_category_5", "Population aged 15 and over: clerical and service employees"},
+	{"employees_category_6", "Population aged 15 and over: manual workers"},
+	{"employees_category_7", "Population aged 15 and over: retirees"},
+	{"employees_category_8", "Population aged 15 and over: other people without professional activity"},
+	{"employees_male", "Male population aged 15 and over"},
+	{"employees_male_category_1", "Male population aged 15 and over: farmers"},
+	{"employees_male_category_2", "Male population aged 15 and over: craftsmen, shopkeepers and business owners"},
+	{"employees_male_category_3", "Male population aged 15 and over: managers and higher intellectual professions"},
+	{"employees_male_category_4", "Male population aged 15 and over: intermediate professions"},
+	{"employees_male_category_5", "Male population aged 15 and over: clerical and service employees"},
+	{"employees_male_category_6", "Male population aged 15 and over: manual workers"},
+	{"employees_male_category_7", "Male population aged 15 and over: retirees"},
+	{"employees_male_category_8", "Male population aged 15 and over: other people without professional activity"},
+	{"employees_female", "Female population aged 15 and over"},
+	{"employees_female_category_1", "Female population aged 15 and over: farmers"},
+	{"employees_female_category_2", "Female population aged 15 and over: craftsmen, shopkeepers and business owners"},
+	{"employees_female_category_3", "Female population aged 15 and over: managers and higher intellectual professions"},
+	{"employees_female_category_4", "Female population aged 15 and over: intermediate professions"},
+	{"employees_female_category_5", "Female population aged 15 and over: clerical and service employees"},
+	{"employees_female_category_6", "Female population aged 15 and over: manual workers"},
+	{"employees_female_category_7", "Female population aged 15 and over: retirees"},
+	{"employees_female_category_8", "Female population aged 15 and over: other people without professional activity"},
+	{"population_french", "French nationals"},
+	{"population_foreign", "Foreign nationals"},
+	{"population_immigrant", "Immigrants"},
+	{"housing_people_per_home", "People living in households"},
+	{"housing_people_in_collective_housing", "People living in collective housing"},
+	{"families_only_number", "Families"},
+	{"families_with_kids", "Couples with children"},
+	{"families_monoparental", "Single-parent families"},
+	{"families_without_kids", "Couples without children"},
+	{"families_with_1_kids_under_25", "Families with 1 child under 25"},
+	{"families_with_2_kids_under_25", "Families with 2 children under 25"},
+	{"families_with_3_kids_under_25", "Families with 3 children under 25"},
+	{"families_with_4p_kids_under_25", "Families with 4 or more children under 25"},
+	{"families_number", "Households"},
+	{"families_one_person", "One-person households"},
+	{"families_living_without_family", "Multi-person households without a family"},
+	{"families_living_with_family", "Households with a family"},
+	{"employees_number", "Active population"},
+	{"students_number", "Students"},
+	{"housing_total", "Dwellings"},
+	{"housing_primary_residence", "Main residences"},
+	{"housing_secondary_residence", "Secondary residences and occasional dwellings"},
+	{"housing_empty_residence", "Vacant dwellings"},
+	{"housing_houses", "Houses"},
+	{"housing_apartments", "Apartments"},
+	{"housing_rooms_1_rooms", "Main residences with 1 room"},
+	{"housing_rooms_2_rooms", "Main residences with 2 rooms"},
+	{"housing_rooms_3_rooms", "Main residences with 3 rooms"},
+	{"housing_rooms_4_rooms", "Main residences with 4 rooms"},
+	{"housing_rooms_5p_rooms", "Main residences with 5 or more rooms"},
+	{"housing_houses_constructed_before_19", "Main residences built before 1919"},
+	{"housing_houses_constructed_19_45", "Main residences built from 1919 to 1945"},
+	{"housing_houses_constructed_46_70", "Main residences built from 1946 to 1970"},
+	{"housing_houses_constructed_71_90", "Main residences built from 1971 to 1990"},
+	{"housing_houses_constructed_91_05", "Main residences built from 1991 to 2005"},
+	{"housing_houses_constructed_06_17", "Main residences built from 2006 to 2017"},
+	{"housing_moved_since_0_2_years", "Households moved in less than 2 years ago"},
+	{"housing_moved_since_2_4_years", "Households moved in 2 to 4 years ago"},
+	{"housing_moved_since_5_9_years", "Households moved in 5 to 9 years ago"},
+	{"housing_moved_since_10p_years", "Households moved in 10 or more years ago"},
+	{"housing_owners", "Owner-occupied main residences"},
+	{"housing_renters", "Rented main residences"},
+	{"housing_with_parkings", "Households with a parking space"},
+	{"housing_with_atleast_1_cars", "Households with at least one car"},
+	{"housing_with_1_cars", "Households with one car"},
+	{"housing_with_2p_cars", "Households with two or more cars"},
+}
+
+// irisStatisticLabelsByKey indexes IrisStatisticLabels by key
+var irisStatisticLabelsByKey = func() map[string]string {
+	labels := make(map[string]string, len(IrisStatisticLabels))
+	for _, label := range IrisStatisticLabels {
+		labels[label.Key] = label.Label
+	}
+	return labels
+}()
+
+// IsIrisStatistic tells whether key is a statistic of the IRIS data
+func IsIrisStatistic(key string) bool {
+	_, exists := irisStatisticLabelsByKey[key]
+	return exists
+}
+
+// IrisStatisticLabel returns the readable label of a statistic or indicator key, or the key with
+// spaces instead of underscores when it has no label
+func IrisStatisticLabel(key string) string {
+	if label, exists := irisStatisticLabelsByKey[key]; exists {
+		return label
+	}
+	if indicator := IrisIndicatorByKey(key); indicator != nil {
+		return indicator.Label
+	}
+	label := strings.ReplaceAll(key, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
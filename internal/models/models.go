@@ -3,7 +3,6 @@ package models
 import (
 	"encoding/json"
 	"fmt"
-	"math"
 
 	geom2 "github.com/peterstace/simplefeatures/geom"
 	"github.com/twpayne/go-geom"
@@ -29,24 +28,128 @@ func FromGeomPoint(gp *geom.Point) Point {
 	}
 }
 
-// SearchRequest represents the search criteria
-type SearchRequest struct {
-	NAFCodes []string `json:"nafCodes"`
+// GeoJSONGeometry represents a GeoJSON Polygon or MultiPolygon geometry.
+// Coordinates always hold a list of polygons, each being its exterior ring
+// followed by its holes, so a Polygon has exactly one entry.
+type GeoJSONGeometry struct {
+	Type        string
+	Coordinates [][][][]float64
+}
+
+// IsPolygonal reports whether the geometry is a Polygon or a MultiPolygon
+func (g GeoJSONGeometry) IsPolygonal() bool {
+	return g.Type == "Polygon" || g.Type == "MultiPolygon"
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for GeoJSONGeometry
+func (g *GeoJSONGeometry) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	g.Type = raw.Type
+	g.Coordinates = nil
+	if len(raw.Coordinates) == 0 {
+		return nil
+	}
+
+	switch raw.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(raw.Coordinates, &rings); err != nil {
+			return fmt.Errorf("invalid Polygon coordinates: %v", err)
+		}
+		g.Coordinates = [][][][]float64{rings}
+	case "MultiPolygon":
+		if err := json.Unmarshal(raw.Coordinates, &g.Coordinates); err != nil {
+			return fmt.Errorf("invalid MultiPolygon coordinates: %v", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements custom JSON marshaling for GeoJSONGeometry
+func (g GeoJSONGeometry) MarshalJSON() ([]byte, error) {
+	var coordinates interface{} = g.Coordinates
+	if g.Type == "Polygon" {
+		var rings [][][]float64
+		if len(g.Coordinates) > 0 {
+			rings = g.Coordinates[0]
+		}
+		coordinates = rings
+	}
+	return json.Marshal(struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}{
+		Type:        g.Type,
+		Coordinates: coordinates,
+	})
+}
+
+// Feature represents a GeoJSON feature of a FeatureCollection request
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+}
+
+// FeatureResult holds the result computed for one feature of a FeatureCollection request
+type FeatureResult struct {
+	ID         interface{}            `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+	Result     interface{}            `json:"result"`
+}
+
+// FeatureCollectionResponse represents the response for a FeatureCollection request,
+// with one result per feature and optionally one over the union of all features
+type FeatureCollectionResponse struct {
+	Features []FeatureResult `json:"features"`
+	Combined interface{}     `json:"combined,omitempty"`
+}
+
+// GeometryRequest holds the area of a request: a GeoJSON Feature or FeatureCollection,
+// or a circle around a center point
+type GeometryRequest struct {
 	Type    string `json:"type"`
 	// For FeatureCollection format
-	Features []struct {
-		Type       string `json:"type"`
-		Properties struct{} `json:"properties"`
-		Geometry   struct {
-			Type        string        `json:"type"`
-			Coordinates [][][]float64 `json:"coordinates"`
-		} `json:"geometry"`
-	} `json:"features"`
+	Features []Feature `json:"features"`
+	// Also compute the result over the union of all features of a FeatureCollection
+	Combined bool `json:"combined"`
 	// For Feature format
-	Geometry struct {
-		Type        string        `json:"type"`
-		Coordinates [][][]float64 `json:"coordinates"`
-	} `json:"geometry"`
+	Geometry GeoJSONGeometry `json:"geometry"`
+	// For radius search, used instead of the geometry when set
+	Center *Point  `json:"center"`
+	Radius float64 `json:"radius"` // in meters
+	// For administrative area requests, resolved to the geometry of the area when set
+	Area *AreaCode `json:"area,omitempty"`
+}
+
+// AreaCode names an administrative area by its type and code, such as an INSEE commune code
+type AreaCode struct {
+	Type string `json:"type"`
+	Code string `json:"code"`
+}
+
+// ResolvedArea is an administrative area named by a request, with the geometry it resolved to
+type ResolvedArea struct {
+	Type     string           `json:"type"`
+	Code     string           `json:"code"`
+	Name     string           `json:"name,omitempty"`
+	Geometry *GeoJSONGeometry `json:"geometry"`
+}
+
+// SearchRequest represents the search criteria
+type SearchRequest struct {
+	NAFCodes []string `json:"nafCodes"`
+	// Add the searched area as a feature of GeoJSON responses
+	IncludeQueryGeometry bool `json:"includeQueryGeometry"`
+	GeometryRequest
 }
 
 // Business represents a business entity
@@ -69,78 +172,6 @@ func (b *Business) ToGeomPoint() *geom.Point {
 	return b.geomPoint
 }
 
-// SpatialIndex represents a spatial index for efficient point-in-polygon queries
-type SpatialIndex struct {
-	businesses []*Business
-	bounds     *geom.Bounds
-}
-
-// NewSpatialIndex creates a new spatial index from a list of businesses
-func NewSpatialIndex(businesses []*Business) *SpatialIndex {
-	if len(businesses) == 0 {
-		return &SpatialIndex{
-			businesses: make([]*Business, 0),
-			bounds:     geom.NewBounds(geom.XY),
-		}
-	}
-
-	// Pre-allocate bounds
-	bounds := geom.NewBounds(geom.XY)
-	minX, minY := math.MaxFloat64, math.MaxFloat64
-	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
-
-	// Calculate bounds in a single pass
-	for _, business := range businesses {
-		if business.Longitude < minX {
-			minX = business.Longitude
-		}
-		if business.Longitude > maxX {
-			maxX = business.Longitude
-		}
-		if business.Latitude < minY {
-			minY = business.Latitude
-		}
-		if business.Latitude > maxY {
-			maxY = business.Latitude
-		}
-	}
-
-	bounds.Set(minX, minY, maxX, maxY)
-
-	return &SpatialIndex{
-		businesses: businesses,
-		bounds:     bounds,
-	}
-}
-
-// Query returns all businesses that are within the given geometry
-func (s *SpatialIndex) Query(geometry geom2.Geometry) []*Business {
-	if len(s.businesses) == 0 {
-		return nil
-	}
-
-	// Pre-allocate result slice with reasonable capacity
-	results := make([]*Business, 0, len(s.businesses)/4)
-
-	// Check each business
-	for _, business := range s.businesses {
-		wkt := fmt.Sprintf("POINT(%f %f)", business.Longitude, business.Latitude)
-		point, err := geom2.UnmarshalWKT(wkt)
-		if err != nil {
-			continue
-		}
-		contains, err := geom2.Contains(geometry, point)
-		if err != nil {
-			continue
-		}
-		if contains {
-			results = append(results, business)
-		}
-	}
-
-	return results
-}
-
 // IrisData represents the demographic data for an IRIS zone
 type IrisData struct {
 	// Raw data storage for all 119 keys
@@ -178,6 +209,7 @@ type IrisData struct {
 
 	// Geographic data
 	Polygon *geom2.Geometry `json:"polygon"`
+	ProjectedPolygon *geom2.Geometry `json:"-"` // Lambert-93, for area calculations
 	Area    float64       `json:"area"`
 
 	// Family data
@@ -233,6 +265,7 @@ type CommuneData struct {
 	Population   float64 `json:"-"`
 	SurfaceArea  float64 `json:"-"`
 	Polygon *geom2.Geometry `json:"-"`
+	ProjectedPolygon *geom2.Geometry `json:"-"` // Lambert-93, for area calculations
 	AverageIncome float64 `json:"-"`
 }
 
@@ -298,9 +331,9 @@ func (s Statistics) MarshalJSON() ([]byte, error) {
 	// Add median income
 	stats["median_income"] = s.MedianIncome
 	
-	// Add all other statistics, rounding to integers
+	// Add all other statistics, already rounded to the precision of the request
 	for k, v := range s.OtherData {
-		stats[k] = int(math.Round(v))
+		stats[k] = v
 	}
 	
 	return json.Marshal(stats)
@@ -309,29 +342,80 @@ func (s Statistics) MarshalJSON() ([]byte, error) {
 // IrisResponse represents the response for the IRIS data endpoint
 type IrisResponse struct {
 	TotalPopulation float64            `json:"totalPopulation"`
+	AreaKm2         float64            `json:"areaKm2"`           // area of the request polygon
+	PopulationDensity float64          `json:"populationDensity"` // inhabitants per km²
 	Data           Statistics         `json:"statistics"`
 	Criminality    CriminalityResponse `json:"criminality"`
 	Administrative AdministrativeData `json:"administrative"`
+	Area           *ResolvedArea      `json:"area,omitempty"` // for administrative area requests
+	Breakdown      []IrisBreakdown    `json:"breakdown,omitempty"`
+}
+
+// IrisBreakdown is the part of an IRIS zone intersecting the request area.
+// Statistics are the zone statistics weighted by its intersection percentage.
+type IrisBreakdown struct {
+	Code                   string             `json:"code"`
+	Label                  string             `json:"label"`
+	Commune                string             `json:"commune"`
+	IntersectionPercentage float64            `json:"percentage"`
+	Population             float64            `json:"population"`
+	Statistics             map[string]float64 `json:"statistics"`
+	Geometry               json.RawMessage    `json:"geometry,omitempty"`
+}
+
+// IrisMetricsResponse lists the statistics and indicators an IRIS data request can select
+type IrisMetricsResponse struct {
+	Statistics []StatisticLabel `json:"statistics"`
+	Indicators []IrisIndicator  `json:"indicators"`
+}
+
+// LocateResponse lists the administrative zones containing a point.
+// Iris and Commune are null for a point outside of every zone of their layer.
+type LocateResponse struct {
+	Point        Point           `json:"point"`
+	Iris         *LocatedIris    `json:"iris"`
+	Commune      *LocatedCommune `json:"commune"`
+	Department   string          `json:"department,omitempty"`
+	SpecialZones []LocatedQP     `json:"special_zones"`
+}
+
+// LocatedIris is the IRIS zone containing a point, with its headline statistics
+type LocatedIris struct {
+	Code              string             `json:"code"`
+	Label             string             `json:"label"`
+	Type              string             `json:"type"`
+	AreaKm2           float64            `json:"area_km2"`
+	PopulationDensity float64            `json:"population_density"` // inhabitants per km²
+	Statistics        map[string]float64 `json:"statistics"`
+}
+
+// LocatedCommune is the commune containing a point
+type LocatedCommune struct {
+	CommuneCode   string   `json:"code_insee"`
+	CommuneName   string   `json:"name"`
+	PostalCodes   []string `json:"postal_codes"`
+	AverageIncome float64  `json:"median_income,omitempty"`
+}
+
+// LocatedQP is a Quartier Prioritaire containing a point
+type LocatedQP struct {
+	CodeQP  string `json:"codeqp"`
+	LibQP   string `json:"name"`
+	Commune string `json:"commune"`
 }
 
 // IrisRequest represents the request for the IRIS data endpoint
 type IrisRequest struct {
-	Type    string `json:"type"`
-	// For FeatureCollection format
-	Features []struct {
-		Type       string `json:"type"`
-		Properties struct{} `json:"properties"`
-		Geometry   struct {
-			Type        string        `json:"type"`
-			Coordinates [][][]float64 `json:"coordinates"`
-		} `json:"geometry"`
-	} `json:"features"`
-	// For Feature format
-	Geometry struct {
-		Type        string        `json:"type"`
-		Coordinates [][][]float64 `json:"coordinates"`
-	} `json:"geometry"`
-} 
+	// List every intersecting IRIS zone, with its polygon when BreakdownGeometry is set
+	Breakdown         bool `json:"breakdown"`
+	BreakdownGeometry bool `json:"breakdownGeometry"`
+	// Statistic and indicator keys to return, every statistic when empty
+	Metrics []string `json:"metrics,omitempty"`
+	// Decimals of the returned values; statistics are rounded to integers and indicators
+	// to their own precision when unset
+	Precision *int `json:"precision,omitempty"`
+	GeometryRequest
+}
 
 // competitor count response
 type CompetitorCountResponse struct {
@@ -388,6 +472,67 @@ type NAFCodeResponse struct {
 // SearchResponse represents the response for the search endpoint
 type SearchResponse struct {
 	NAFCodes []NAFCodeResponse `json:"naf_codes"`
+	Area     *ResolvedArea     `json:"area,omitempty"` // for administrative area requests
+}
+
+// StreamedBusiness is a business line of a streamed search response. Feature is the index
+// of the request feature the business was found in, for FeatureCollection requests.
+type StreamedBusiness struct {
+	Type    string `json:"type"`
+	Feature *int   `json:"feature,omitempty"`
+	*Business
+}
+
+// NAFCodeCount holds the number of businesses found for a NAF code
+type NAFCodeCount struct {
+	NAFCode            string `json:"naf_code"`
+	NumberOfBusinesses int    `json:"number_of_businesses"`
+}
+
+// StreamSummary is the last line of a streamed search response
+type StreamSummary struct {
+	Type               string         `json:"type"`
+	NumberOfBusinesses int            `json:"number_of_businesses"`
+	NAFCodes           []NAFCodeCount `json:"naf_codes"`
+}
+
+// GeoJSONPoint represents a GeoJSON Point geometry
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// GeoJSONFeature represents a feature of a GeoJSON FeatureCollection response
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   interface{}            `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONFeatureCollection represents a GeoJSON FeatureCollection response
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// NewBusinessFeature creates a Point feature locating a business, with its details as properties
+func NewBusinessFeature(business *Business) GeoJSONFeature {
+	return GeoJSONFeature{
+		Type: "Feature",
+		ID:   business.Siret,
+		Geometry: GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{business.Longitude, business.Latitude},
+		},
+		Properties: map[string]interface{}{
+			"kind":    "business",
+			"name":    business.Name,
+			"siret":   business.Siret,
+			"nafCode": business.NAFCode,
+			"address": business.Address,
+		},
+	}
 }
 
 // CompetitorWithData represents a competitor with its basic info and competition data
@@ -410,6 +555,13 @@ type NAFCodeCompetitionResponse struct {
 type CompetitionResponseByNAF struct {
 	NAFCodes []NAFCodeCompetitionResponse `json:"naf_codes"`
 	Averages CompetitionResponse `json:"averages"`
+	Area *ResolvedArea `json:"area,omitempty"` // for administrative area requests
+}
+
+// CompetitorDetails pairs a competitor with its competition data, nil when none was found
+type CompetitorDetails struct {
+	Business *Business
+	Data     *BusinessData
 }
 
 // BusinessData represents the competition data for a business
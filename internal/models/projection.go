@@ -0,0 +1,123 @@
+package models
+
+import (
+	"math"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// Lambert-93 (EPSG:2154) parameters: Lambert Conformal Conic with two standard
+// parallels on the GRS80 ellipsoid
+const (
+	lambert93SemiMajorAxis = 6378137.0
+	lambert93Flattening    = 1 / 298.257222101
+	lambert93Parallel1     = 49.0
+	lambert93Parallel2     = 44.0
+	lambert93OriginLat     = 46.5
+	lambert93OriginLng     = 3.0
+	lambert93FalseEasting  = 700000.0
+	lambert93FalseNorthing = 6600000.0
+)
+
+// lambert93 holds the projection constants derived from the parameters
+var lambert93 = newLambertConicConformal()
+
+// lambertConicConformal holds the derived constants of a Lambert Conformal Conic projection
+type lambertConicConformal struct {
+	e    float64 // ellipsoid eccentricity
+	n    float64 // cone constant
+	aF   float64 // semi-major axis times the F constant
+	rho0 float64 // radius of the origin parallel
+	lng0 float64 // central meridian in radians
+}
+
+func newLambertConicConformal() lambertConicConformal {
+	e := math.Sqrt(2*lambert93Flattening - lambert93Flattening*lambert93Flattening)
+	m := func(phi float64) float64 {
+		return math.Cos(phi) / math.Sqrt(1-e*e*math.Sin(phi)*math.Sin(phi))
+	}
+	t := func(phi float64) float64 {
+		return math.Tan(math.Pi/4-phi/2) / math.Pow((1-e*math.Sin(phi))/(1+e*math.Sin(phi)), e/2)
+	}
+
+	phi1 := lambert93Parallel1 * math.Pi / 180
+	phi2 := lambert93Parallel2 * math.Pi / 180
+	phi0 := lambert93OriginLat * math.Pi / 180
+
+	n := (math.Log(m(phi1)) - math.Log(m(phi2))) / (math.Log(t(phi1)) - math.Log(t(phi2)))
+	aF := lambert93SemiMajorAxis * m(phi1) / (n * math.Pow(t(phi1), n))
+
+	return lambertConicConformal{
+		e:    e,
+		n:    n,
+		aF:   aF,
+		rho0: aF * math.Pow(t(phi0), n),
+		lng0: lambert93OriginLng * math.Pi / 180,
+	}
+}
+
+// ToLambert93 projects WGS84 (EPSG:4326) longitude and latitude to Lambert-93 (EPSG:2154) meters
+func ToLambert93(lng, lat float64) (float64, float64) {
+	l := lambert93
+	phi := lat * math.Pi / 180
+	sinPhi := math.Sin(phi)
+	t := math.Tan(math.Pi/4-phi/2) / math.Pow((1-l.e*sinPhi)/(1+l.e*sinPhi), l.e/2)
+	rho := l.aF * math.Pow(t, l.n)
+	theta := l.n * (lng*math.Pi/180 - l.lng0)
+
+	x := lambert93FalseEasting + rho*math.Sin(theta)
+	y := lambert93FalseNorthing + l.rho0 - rho*math.Cos(theta)
+	return x, y
+}
+
+// ProjectToLambert93 returns a copy of the polygons of a WGS84 geometry projected to Lambert-93.
+// Parts that are not polygons are dropped since they have no area.
+func ProjectToLambert93(geometry geom2.Geometry) geom2.Geometry {
+	var polygons []geom2.Polygon
+	collectProjectedPolygons(geometry, &polygons)
+	if len(polygons) == 1 {
+		return polygons[0].AsGeometry()
+	}
+	return geom2.NewMultiPolygon(polygons).AsGeometry()
+}
+
+// collectProjectedPolygons appends the projected polygons found in the geometry
+func collectProjectedPolygons(geometry geom2.Geometry, polygons *[]geom2.Polygon) {
+	if polygon, ok := geometry.AsPolygon(); ok {
+		*polygons = append(*polygons, projectPolygon(polygon))
+	} else if multiPolygon, ok := geometry.AsMultiPolygon(); ok {
+		for i := 0; i < multiPolygon.NumPolygons(); i++ {
+			*polygons = append(*polygons, projectPolygon(multiPolygon.PolygonN(i)))
+		}
+	} else if collection, ok := geometry.AsGeometryCollection(); ok {
+		for i := 0; i < collection.NumGeometries(); i++ {
+			collectProjectedPolygons(collection.GeometryN(i), polygons)
+		}
+	}
+}
+
+// projectPolygon projects every ring of a polygon
+func projectPolygon(polygon geom2.Polygon) geom2.Polygon {
+	if polygon.IsEmpty() {
+		return polygon
+	}
+
+	rings := make([]geom2.LineString, 0, 1+polygon.NumInteriorRings())
+	rings = append(rings, projectLineString(polygon.ExteriorRing()))
+	for i := 0; i < polygon.NumInteriorRings(); i++ {
+		rings = append(rings, projectLineString(polygon.InteriorRingN(i)))
+	}
+	return geom2.NewPolygon(rings)
+}
+
+// projectLineString projects every point of a line string
+func projectLineString(lineString geom2.LineString) geom2.LineString {
+	sequence := lineString.Coordinates()
+	coords := make([]float64, 0, sequence.Length()*2)
+	for i := 0; i < sequence.Length(); i++ {
+		xy := sequence.GetXY(i)
+		x, y := ToLambert93(xy.X, xy.Y)
+		coords = append(coords, x, y)
+	}
+	return geom2.NewLineString(geom2.NewSequence(coords, geom2.DimXY))
+}
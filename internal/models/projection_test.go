@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+func TestToLambert93(t *testing.T) {
+	tests := []struct {
+		name     string
+		lng, lat float64
+		x, y     float64
+	}{
+		// The projection origin maps to the false easting and northing
+		{"origin", 3, 46.5, 700000, 6600000},
+		{"Paris", 2.3522, 48.8566, 652469, 6862035},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := ToLambert93(tt.lng, tt.lat)
+			if math.Abs(x-tt.x) > 1 || math.Abs(y-tt.y) > 1 {
+				t.Errorf("ToLambert93(%v, %v) = (%.1f, %.1f), want (%v, %v)", tt.lng, tt.lat, x, y, tt.x, tt.y)
+			}
+		})
+	}
+}
+
+func TestProjectToLambert93Area(t *testing.T) {
+	// A 0.01° square in Paris covers 0.8160 km² of the GRS80 ellipsoid, a hole of a quarter of it
+	// removes 0.2040 km², and a point has no area
+	square := "POLYGON((2.35 48.85,2.36 48.85,2.36 48.86,2.35 48.86,2.35 48.85))"
+	hole := "(2.3525 48.8525,2.3525 48.8575,2.3575 48.8575,2.3575 48.8525,2.3525 48.8525)"
+	tests := []struct {
+		name string
+		wkt  string
+		want float64
+	}{
+		{"square", square, 0.8160},
+		{"square with a hole", square[:len(square)-1] + "," + hole + ")", 0.6120},
+		{"collection", "GEOMETRYCOLLECTION(POINT(2.35 48.85)," + square + ")", 0.8160},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geometry, err := geom2.UnmarshalWKT(tt.wkt)
+			if err != nil {
+				t.Fatalf("UnmarshalWKT() error = %v", err)
+			}
+			areaKm2 := ProjectToLambert93(geometry).Area() / 1e6
+			// Lambert-93 is conformal, not equal-area, but its scale error stays far below 0.1% in Paris
+			if math.Abs(areaKm2-tt.want) > tt.want*0.001 {
+				t.Errorf("area = %.4f km², want %.4f km²", areaKm2, tt.want)
+			}
+		})
+	}
+}
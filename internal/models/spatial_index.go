@@ -0,0 +1,496 @@
+package models
+
+import (
+	"cmp"
+	"math"
+
+	"golang.org/x/exp/slices"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+	"github.com/twpayne/go-geom"
+)
+
+// spatialIndexNodeSize is the maximum number of entries of an R-tree node
+const spatialIndexNodeSize = 16
+
+// Envelope is an axis-aligned bounding box in lon/lat coordinates
+type Envelope struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// EmptyEnvelope returns an envelope that contains nothing and grows with Extend
+func EmptyEnvelope() Envelope {
+	return Envelope{
+		MinX: math.MaxFloat64,
+		MinY: math.MaxFloat64,
+		MaxX: -math.MaxFloat64,
+		MaxY: -math.MaxFloat64,
+	}
+}
+
+// IsEmpty reports whether the envelope has never been extended
+func (e Envelope) IsEmpty() bool {
+	return e.MinX > e.MaxX || e.MinY > e.MaxY
+}
+
+// Extend grows the envelope so that it contains the given point
+func (e *Envelope) Extend(x, y float64) {
+	e.MinX = math.Min(e.MinX, x)
+	e.MinY = math.Min(e.MinY, y)
+	e.MaxX = math.Max(e.MaxX, x)
+	e.MaxY = math.Max(e.MaxY, y)
+}
+
+// Merge grows the envelope so that it contains the other envelope
+func (e *Envelope) Merge(other Envelope) {
+	e.MinX = math.Min(e.MinX, other.MinX)
+	e.MinY = math.Min(e.MinY, other.MinY)
+	e.MaxX = math.Max(e.MaxX, other.MaxX)
+	e.MaxY = math.Max(e.MaxY, other.MaxY)
+}
+
+// Intersects reports whether the two envelopes overlap
+func (e Envelope) Intersects(other Envelope) bool {
+	return e.MinX <= other.MaxX && other.MinX <= e.MaxX && e.MinY <= other.MaxY && other.MinY <= e.MaxY
+}
+
+// ContainsPoint reports whether the point lies inside the envelope
+func (e Envelope) ContainsPoint(x, y float64) bool {
+	return x >= e.MinX && x <= e.MaxX && y >= e.MinY && y <= e.MaxY
+}
+
+// spatialNode is an R-tree node. Its children are the entries [start, end)
+// of the level below, or of the points for the leaf level.
+type spatialNode struct {
+	envelope   Envelope
+	start, end int32
+}
+
+// SpatialIndex is a packed R-tree built with the Sort-Tile-Recursive algorithm.
+// It is immutable once built and safe for concurrent queries.
+type SpatialIndex struct {
+	businesses []*Business
+	// Point coordinates and their position in the input, in tree order
+	xs  []float64
+	ys  []float64
+	ids []int32
+	// levels[0] holds the leaves and the last level holds the root
+	levels [][]spatialNode
+	bounds *geom.Bounds
+}
+
+// NewSpatialIndex creates a new spatial index from a list of businesses
+func NewSpatialIndex(businesses []*Business) *SpatialIndex {
+	xs := make([]float64, len(businesses))
+	ys := make([]float64, len(businesses))
+	for i, business := range businesses {
+		xs[i] = business.Longitude
+		ys[i] = business.Latitude
+	}
+
+	index := NewPointIndex(xs, ys)
+	index.businesses = businesses
+	return index
+}
+
+// NewPointIndex creates a spatial index over points given as longitudes and latitudes.
+// Query results identify points by their position in the input slices.
+func NewPointIndex(xs, ys []float64) *SpatialIndex {
+	n := len(xs)
+	index := &SpatialIndex{
+		xs:     make([]float64, n),
+		ys:     make([]float64, n),
+		ids:    make([]int32, n),
+		bounds: geom.NewBounds(geom.XY),
+	}
+	if n == 0 {
+		return index
+	}
+
+	order := make([]int32, n)
+	for i := range order {
+		order[i] = int32(i)
+	}
+	strSort(order, func(i int32) float64 { return xs[i] }, func(i int32) float64 { return ys[i] })
+
+	for i, id := range order {
+		index.xs[i] = xs[id]
+		index.ys[i] = ys[id]
+		index.ids[i] = id
+	}
+
+	// Pack the points into leaves
+	leaves := make([]spatialNode, 0, (n+spatialIndexNodeSize-1)/spatialIndexNodeSize)
+	for start := 0; start < n; start += spatialIndexNodeSize {
+		end := min(start+spatialIndexNodeSize, n)
+		node := spatialNode{envelope: EmptyEnvelope(), start: int32(start), end: int32(end)}
+		for i := start; i < end; i++ {
+			node.envelope.Extend(index.xs[i], index.ys[i])
+		}
+		leaves = append(leaves, node)
+	}
+	index.levels = packLevels(leaves)
+
+	root := index.levels[len(index.levels)-1][0].envelope
+	index.bounds.Set(root.MinX, root.MinY, root.MaxX, root.MaxY)
+	return index
+}
+
+// packLevels packs the leaves into parent levels until a single root remains.
+// Nodes of each level are reordered with STR before being grouped, so the
+// child ranges of a parent always refer to the reordered level below.
+func packLevels(leaves []spatialNode) [][]spatialNode {
+	levels := [][]spatialNode{leaves}
+	for len(levels[len(levels)-1]) > 1 {
+		children := levels[len(levels)-1]
+		positions := make([]int32, len(children))
+		for i := range positions {
+			positions[i] = int32(i)
+		}
+		strSort(positions,
+			func(i int32) float64 { return (children[i].envelope.MinX + children[i].envelope.MaxX) / 2 },
+			func(i int32) float64 { return (children[i].envelope.MinY + children[i].envelope.MaxY) / 2 })
+
+		sorted := make([]spatialNode, len(children))
+		for i, position := range positions {
+			sorted[i] = children[position]
+		}
+		levels[len(levels)-1] = sorted
+
+		parents := make([]spatialNode, 0, (len(sorted)+spatialIndexNodeSize-1)/spatialIndexNodeSize)
+		for start := 0; start < len(sorted); start += spatialIndexNodeSize {
+			end := min(start+spatialIndexNodeSize, len(sorted))
+			node := spatialNode{envelope: EmptyEnvelope(), start: int32(start), end: int32(end)}
+			for i := start; i < end; i++ {
+				node.envelope.Merge(sorted[i].envelope)
+			}
+			parents = append(parents, node)
+		}
+		levels = append(levels, parents)
+	}
+	return levels
+}
+
+// strSort orders entries with the Sort-Tile-Recursive algorithm: entries are
+// sorted by x, cut into vertical slices, and each slice is sorted by y.
+func strSort(entries []int32, x, y func(int32) float64) {
+	n := len(entries)
+	nodeCount := (n + spatialIndexNodeSize - 1) / spatialIndexNodeSize
+	sliceCount := int(math.Ceil(math.Sqrt(float64(nodeCount))))
+	sliceSize := sliceCount * spatialIndexNodeSize
+
+	slices.SortFunc(entries, func(a, b int32) int { return cmp.Compare(x(a), x(b)) })
+	for start := 0; start < n; start += sliceSize {
+		slices.SortFunc(entries[start:min(start+sliceSize, n)], func(a, b int32) int { return cmp.Compare(y(a), y(b)) })
+	}
+}
+
+// Len returns the number of indexed points
+func (s *SpatialIndex) Len() int {
+	return len(s.ids)
+}
+
+// SizeBytes returns the approximate memory footprint of the index
+func (s *SpatialIndex) SizeBytes() int {
+	size := cap(s.xs)*8 + cap(s.ys)*8 + cap(s.ids)*4
+	for _, level := range s.levels {
+		size += cap(level) * 40
+	}
+	return size
+}
+
+// Bounds returns the bounding box of all indexed points
+func (s *SpatialIndex) Bounds() *geom.Bounds {
+	return s.bounds
+}
+
+// Query returns all businesses that are within the given geometry
+func (s *SpatialIndex) Query(geometry geom2.Geometry) []*Business {
+	positions := s.QueryPositions(geometry)
+	if len(positions) == 0 || s.businesses == nil {
+		return nil
+	}
+
+	results := make([]*Business, len(positions))
+	for i, position := range positions {
+		results[i] = s.businesses[position]
+	}
+	return results
+}
+
+// QueryPositions returns the input positions of the points within the given geometry
+func (s *SpatialIndex) QueryPositions(geometry geom2.Geometry) []int {
+	polygons := NewPolygonSet(geometry)
+	if polygons.IsEmpty() {
+		return nil
+	}
+	return s.QueryWithin(polygons.Envelope(), polygons.ContainsPoint)
+}
+
+// QueryWithin returns the input positions of the points inside the envelope
+// for which contains returns true
+func (s *SpatialIndex) QueryWithin(envelope Envelope, contains func(x, y float64) bool) []int {
+	if len(s.ids) == 0 || envelope.IsEmpty() {
+		return nil
+	}
+
+	var results []int
+	s.search(len(s.levels)-1, 0, envelope, func(i int) {
+		if contains(s.xs[i], s.ys[i]) {
+			results = append(results, int(s.ids[i]))
+		}
+	})
+
+	// Keep results in input order, as the linear scan did
+	slices.Sort(results)
+	return results
+}
+
+// VisitWithin calls visit with the input position of every point inside the envelope for
+// which contains returns true, in index order rather than input order, and stops at the
+// first call returning false. Unlike QueryWithin it holds no results in memory.
+func (s *SpatialIndex) VisitWithin(envelope Envelope, contains func(x, y float64) bool, visit func(position int) bool) {
+	if len(s.ids) == 0 || envelope.IsEmpty() {
+		return
+	}
+
+	stopped := false
+	s.search(len(s.levels)-1, 0, envelope, func(i int) {
+		if stopped || !contains(s.xs[i], s.ys[i]) {
+			return
+		}
+		stopped = !visit(int(s.ids[i]))
+	})
+}
+
+// search visits every point of the subtree rooted at levels[level][node] that lies inside the envelope
+func (s *SpatialIndex) search(level int, node int32, envelope Envelope, visit func(int)) {
+	current := s.levels[level][node]
+	if !current.envelope.Intersects(envelope) {
+		return
+	}
+
+	if level == 0 {
+		for i := current.start; i < current.end; i++ {
+			if envelope.ContainsPoint(s.xs[i], s.ys[i]) {
+				visit(int(i))
+			}
+		}
+		return
+	}
+
+	for child := current.start; child < current.end; child++ {
+		s.search(level-1, child, envelope, visit)
+	}
+}
+
+// EnvelopeIndex is a packed R-tree over bounding boxes, used to find the zones
+// whose envelope overlaps a query envelope before any exact geometry test.
+type EnvelopeIndex struct {
+	// Envelopes and their position in the input, in tree order
+	envelopes []Envelope
+	ids       []int32
+	levels    [][]spatialNode
+}
+
+// NewEnvelopeIndex creates an index over the given envelopes
+func NewEnvelopeIndex(envelopes []Envelope) *EnvelopeIndex {
+	n := len(envelopes)
+	index := &EnvelopeIndex{
+		envelopes: make([]Envelope, n),
+		ids:       make([]int32, n),
+	}
+	if n == 0 {
+		return index
+	}
+
+	order := make([]int32, n)
+	for i := range order {
+		order[i] = int32(i)
+	}
+	strSort(order,
+		func(i int32) float64 { return (envelopes[i].MinX + envelopes[i].MaxX) / 2 },
+		func(i int32) float64 { return (envelopes[i].MinY + envelopes[i].MaxY) / 2 })
+
+	for i, id := range order {
+		index.envelopes[i] = envelopes[id]
+		index.ids[i] = id
+	}
+
+	leaves := make([]spatialNode, 0, (n+spatialIndexNodeSize-1)/spatialIndexNodeSize)
+	for start := 0; start < n; start += spatialIndexNodeSize {
+		end := min(start+spatialIndexNodeSize, n)
+		node := spatialNode{envelope: EmptyEnvelope(), start: int32(start), end: int32(end)}
+		for i := start; i < end; i++ {
+			node.envelope.Merge(index.envelopes[i])
+		}
+		leaves = append(leaves, node)
+	}
+	index.levels = packLevels(leaves)
+	return index
+}
+
+// Len returns the number of indexed envelopes
+func (e *EnvelopeIndex) Len() int {
+	return len(e.ids)
+}
+
+// Search returns the input positions of the envelopes that intersect the given envelope
+func (e *EnvelopeIndex) Search(envelope Envelope) []int {
+	if len(e.ids) == 0 || envelope.IsEmpty() {
+		return nil
+	}
+
+	var results []int
+	e.search(len(e.levels)-1, 0, envelope, &results)
+	slices.Sort(results)
+	return results
+}
+
+// search collects the matching entries of the subtree rooted at levels[level][node]
+func (e *EnvelopeIndex) search(level int, node int32, envelope Envelope, results *[]int) {
+	current := e.levels[level][node]
+	if !current.envelope.Intersects(envelope) {
+		return
+	}
+
+	if level == 0 {
+		for i := current.start; i < current.end; i++ {
+			if e.envelopes[i].Intersects(envelope) {
+				*results = append(*results, int(e.ids[i]))
+			}
+		}
+		return
+	}
+
+	for child := current.start; child < current.end; child++ {
+		e.search(level-1, child, envelope, results)
+	}
+}
+
+// GeometryEnvelope returns the bounding box of the polygons of a geometry
+func GeometryEnvelope(geometry geom2.Geometry) Envelope {
+	return NewPolygonSet(geometry).Envelope()
+}
+
+// ring is a closed sequence of lon/lat coordinates stored as x0, y0, x1, y1, ...
+type ring []float64
+
+// locatePoint tests the point against the ring with the even-odd rule, and tells whether it lies
+// on one of its edges
+func (r ring) locatePoint(x, y float64) (inside, onBoundary bool) {
+	n := len(r) / 2
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := r[2*i], r[2*i+1]
+		xj, yj := r[2*j], r[2*j+1]
+		if (xj-xi)*(y-yi) == (yj-yi)*(x-xi) &&
+			math.Min(xi, xj) <= x && x <= math.Max(xi, xj) && math.Min(yi, yj) <= y && y <= math.Max(yi, yj) {
+			return false, true
+		}
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside, false
+}
+
+// polygonRings is a polygon as its exterior ring followed by its holes
+type polygonRings struct {
+	envelope Envelope
+	rings    []ring
+}
+
+// PolygonSet is a lightweight copy of the polygons of a geometry, used to test
+// points directly against their coordinates.
+type PolygonSet struct {
+	envelope Envelope
+	polygons []polygonRings
+}
+
+// NewPolygonSet extracts the polygons of a Polygon, MultiPolygon or GeometryCollection
+func NewPolygonSet(geometry geom2.Geometry) *PolygonSet {
+	set := &PolygonSet{envelope: EmptyEnvelope()}
+	set.add(geometry)
+	return set
+}
+
+// add appends the polygons found in the geometry
+func (p *PolygonSet) add(geometry geom2.Geometry) {
+	if polygon, ok := geometry.AsPolygon(); ok {
+		p.addPolygon(polygon)
+	} else if multiPolygon, ok := geometry.AsMultiPolygon(); ok {
+		for i := 0; i < multiPolygon.NumPolygons(); i++ {
+			p.addPolygon(multiPolygon.PolygonN(i))
+		}
+	} else if collection, ok := geometry.AsGeometryCollection(); ok {
+		for i := 0; i < collection.NumGeometries(); i++ {
+			p.add(collection.GeometryN(i))
+		}
+	}
+}
+
+// addPolygon appends a single polygon with all of its rings
+func (p *PolygonSet) addPolygon(polygon geom2.Polygon) {
+	if polygon.IsEmpty() {
+		return
+	}
+
+	lineStrings := make([]geom2.LineString, 0, 1+polygon.NumInteriorRings())
+	lineStrings = append(lineStrings, polygon.ExteriorRing())
+	for i := 0; i < polygon.NumInteriorRings(); i++ {
+		lineStrings = append(lineStrings, polygon.InteriorRingN(i))
+	}
+
+	rings := polygonRings{envelope: EmptyEnvelope()}
+	for _, lineString := range lineStrings {
+		sequence := lineString.Coordinates()
+		coords := make(ring, 0, sequence.Length()*2)
+		for i := 0; i < sequence.Length(); i++ {
+			xy := sequence.GetXY(i)
+			coords = append(coords, xy.X, xy.Y)
+			rings.envelope.Extend(xy.X, xy.Y)
+		}
+		rings.rings = append(rings.rings, coords)
+	}
+
+	p.envelope.Merge(rings.envelope)
+	p.polygons = append(p.polygons, rings)
+}
+
+// IsEmpty reports whether the set holds no polygon
+func (p *PolygonSet) IsEmpty() bool {
+	return len(p.polygons) == 0
+}
+
+// Envelope returns the bounding box of all polygons
+func (p *PolygonSet) Envelope() Envelope {
+	return p.envelope
+}
+
+// ContainsPoint reports whether the point is inside any polygon and outside its holes.
+// Points on an edge are outside, as with geom.Contains.
+func (p *PolygonSet) ContainsPoint(x, y float64) bool {
+	if !p.envelope.ContainsPoint(x, y) {
+		return false
+	}
+	for _, polygon := range p.polygons {
+		if !polygon.envelope.ContainsPoint(x, y) {
+			continue
+		}
+		inside := false
+		for _, r := range polygon.rings {
+			inRing, onBoundary := r.locatePoint(x, y)
+			if onBoundary {
+				inside = false
+				break
+			}
+			if inRing {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"csv-processor/internal/geomtest"
+
+	"golang.org/x/exp/slices"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// benchmarkBusinesses returns businesses spread uniformly over metropolitan France
+func benchmarkBusinesses(n int) []*Business {
+	random := rand.New(rand.NewSource(42))
+	businesses := make([]*Business, n)
+	for i := range businesses {
+		businesses[i] = &Business{
+			Siret:     fmt.Sprintf("%014d", i),
+			Longitude: -5 + random.Float64()*13,
+			Latitude:  42 + random.Float64()*9,
+		}
+	}
+	return businesses
+}
+
+// linearScanQuery is the previous SpatialIndex.Query: a WKT round trip and a Contains test per business
+func linearScanQuery(businesses []*Business, geometry geom2.Geometry) []*Business {
+	results := make([]*Business, 0, len(businesses)/4)
+	for _, business := range businesses {
+		wkt := fmt.Sprintf("POINT(%f %f)", business.Longitude, business.Latitude)
+		point, err := geom2.UnmarshalWKT(wkt)
+		if err != nil {
+			continue
+		}
+		contains, err := geom2.Contains(geometry, point)
+		if err != nil {
+			continue
+		}
+		if contains {
+			results = append(results, business)
+		}
+	}
+	return results
+}
+
+func BenchmarkSpatialIndexBuild(b *testing.B) {
+	businesses := benchmarkBusinesses(200000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewSpatialIndex(businesses)
+	}
+}
+
+func BenchmarkSpatialIndexQuery(b *testing.B) {
+	businesses := benchmarkBusinesses(200000)
+	index := NewSpatialIndex(businesses)
+	for _, radius := range []float64{0.01, 0.1, 1} {
+		polygon := geomtest.Circle(radius)
+		b.Run(fmt.Sprintf("rtree/radius=%g", radius), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				index.Query(polygon)
+			}
+		})
+		b.Run(fmt.Sprintf("scan/radius=%g", radius), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				linearScanQuery(businesses, polygon)
+			}
+		})
+	}
+}
+
+// gridBusinesses returns businesses on a grid of step 0.05 degrees around the test polygons, so
+// that many of them lie exactly on polygon edges and envelope edges
+func gridBusinesses() []*Business {
+	var businesses []*Business
+	for i := 36; i <= 72; i++ {
+		for j := 956; j <= 984; j++ {
+			businesses = append(businesses, &Business{
+				Siret:     fmt.Sprintf("%03d-%03d", i, j),
+				Longitude: float64(i) / 20,
+				Latitude:  float64(j) / 20,
+			})
+		}
+	}
+	return businesses
+}
+
+// mustWKT parses a WKT geometry
+func mustWKT(t *testing.T, wkt string) geom2.Geometry {
+	t.Helper()
+	geometry, err := geom2.UnmarshalWKT(wkt)
+	if err != nil {
+		t.Fatalf("invalid WKT %s: %v", wkt, err)
+	}
+	return geometry
+}
+
+// sirets returns the sorted SIRETs of businesses
+func sirets(businesses []*Business) []string {
+	result := make([]string, len(businesses))
+	for i, business := range businesses {
+		result[i] = business.Siret
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestSpatialIndexQueryMatchesLinearScan(t *testing.T) {
+	businesses := gridBusinesses()
+	index := NewSpatialIndex(businesses)
+
+	tests := []struct {
+		name string
+		wkt  string
+	}{
+		{"rectangle", "POLYGON((2 48,3 48,3 49,2 49,2 48))"},
+		{"polygon with hole", "POLYGON((2 48,3 48,3 49,2 49,2 48),(2.4 48.4,2.6 48.4,2.6 48.6,2.4 48.6,2.4 48.4))"},
+		{"multipolygon", "MULTIPOLYGON(((2 48,2.5 48,2.5 48.5,2 48.5,2 48)),((3 48.5,3.5 48.5,3.5 49,3 49,3 48.5)))"},
+		{"triangle", "POLYGON((2.03 48.03,3.47 48.21,2.61 49.17,2.03 48.03))"},
+		{"outside every business", "POLYGON((10 40,11 40,11 41,10 41,10 40))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geometry := mustWKT(t, tt.wkt)
+			got := sirets(index.Query(geometry))
+			want := sirets(linearScanQuery(businesses, geometry))
+			if !slices.Equal(got, want) {
+				t.Errorf("Query returned %d businesses, linear scan %d\ngot:  %v\nwant: %v", len(got), len(want), got, want)
+			}
+		})
+	}
+}
+
+func TestSpatialIndexQueryEmptyIndex(t *testing.T) {
+	index := NewSpatialIndex(nil)
+	geometry := mustWKT(t, "POLYGON((2 48,3 48,3 49,2 49,2 48))")
+	if results := index.Query(geometry); len(results) != 0 {
+		t.Errorf("Query on an empty index returned %d businesses", len(results))
+	}
+	if index.Len() != 0 {
+		t.Errorf("Len = %d, want 0", index.Len())
+	}
+}
@@ -0,0 +1,187 @@
+package results
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore stores every result as a JSON file named after its ID
+type FileStore struct {
+	dir       string
+	retention Retention
+	mu        sync.Mutex // serializes the retention sweeps
+}
+
+// NewFileStore creates a store writing to dir, which is created if needed
+func NewFileStore(dir string, retention Retention) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating results directory: %v", err)
+	}
+	return &FileStore{dir: dir, retention: retention}, nil
+}
+
+// Save writes the result to a new file, then deletes the results beyond the retention limits
+func (s *FileStore) Save(kind string, request, result interface{}) (string, error) {
+	record, err := newRecord(kind, request, result)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first so that readers never see a partial result
+	file, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating results file: %v", err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(record)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(record.ID))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("error writing results file: %v", err)
+	}
+
+	s.sweep()
+	return record.ID, nil
+}
+
+// Get reads a stored result
+func (s *FileStore) Get(id string) (*Record, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading results file: %v", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid results file %s: %v", s.path(id), err)
+	}
+	return &record, nil
+}
+
+// List reads the selected records, skipping their results. Files are read from the most recent
+// one until the limit is reached, so a filter on the kind may read many files.
+func (s *FileStore) List(filter Filter) ([]*Record, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*Record{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+		// IDs sort by creation time, so the remaining ones are older
+		created, err := idTime(ids[i])
+		if err != nil || created.Before(filter.Since) {
+			break
+		}
+
+		data, err := os.ReadFile(s.path(ids[i]))
+		if err != nil {
+			// Expired results may be deleted meanwhile
+			if !os.IsNotExist(err) {
+				log.Printf("Warning: error reading results file: %v", err)
+			}
+			continue
+		}
+		// The result is left out of the decoded fields
+		var header struct {
+			Kind      string          `json:"type"`
+			CreatedAt time.Time       `json:"created_at"`
+			Request   json.RawMessage `json:"request"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil {
+			log.Printf("Warning: invalid results file %s: %v", s.path(ids[i]), err)
+			continue
+		}
+		if filter.matches(header.Kind, header.CreatedAt) {
+			records = append(records, &Record{ID: ids[i], Kind: header.Kind, CreatedAt: header.CreatedAt, Request: header.Request})
+		}
+	}
+	return records, nil
+}
+
+// Close releases nothing, files being closed after every operation
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// path returns the file of a result
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// ids returns the IDs of the stored results, oldest first
+func (s *FileStore) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading results directory: %v", err)
+	}
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		id, isJSON := strings.CutSuffix(entry.Name(), ".json")
+		if isJSON && validID(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// sweep deletes the results older than the maximum age and the oldest ones beyond the maximum count.
+// Files of other programs and of earlier versions are left alone.
+func (s *FileStore) sweep() {
+	if s.retention.MaxAge <= 0 && s.retention.MaxCount <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.ids()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	expired := 0
+	if s.retention.MaxCount > 0 && len(ids) > s.retention.MaxCount {
+		expired = len(ids) - s.retention.MaxCount
+	}
+	if s.retention.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.retention.MaxAge)
+		for expired < len(ids) {
+			created, err := idTime(ids[expired])
+			if err != nil || !created.Before(cutoff) {
+				break
+			}
+			expired++
+		}
+	}
+
+	for _, id := range ids[:expired] {
+		if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Warning: error deleting expired result %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package results stores the results computed by the API so that they can be fetched again by ID.
+package results
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"csv-processor/internal/config"
+)
+
+// Kinds of stored results
+const (
+	KindSearch = "search"
+	KindIris   = "iris"
+)
+
+// Backends of the result store
+const (
+	BackendDisabled   = "disabled"
+	BackendFilesystem = "filesystem"
+	BackendSQLite     = "sqlite"
+)
+
+// ErrNotFound is returned for an ID that is not in the store, or no longer
+var ErrNotFound = errors.New("result not found")
+
+// Record is a stored result along with the request it answered.
+// Records returned by List have no Result.
+type Record struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+}
+
+// Filter selects the records returned by List. Zero fields select every record.
+type Filter struct {
+	Kind  string
+	Since time.Time
+	Limit int
+}
+
+// matches tells whether a record created at the given time with the given kind is selected
+func (f Filter) matches(kind string, createdAt time.Time) bool {
+	return (f.Kind == "" || f.Kind == kind) && !createdAt.Before(f.Since)
+}
+
+// Store keeps results. Save returns the ID of the stored result, empty when the store is disabled.
+// List returns the selected records, most recent first.
+type Store interface {
+	Save(kind string, request, result interface{}) (string, error)
+	Get(id string) (*Record, error)
+	List(filter Filter) ([]*Record, error)
+	Close() error
+}
+
+// Retention limits the results kept by a store. A zero field disables its limit.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+// New creates the store configured by cfg
+func New(cfg config.ResultsConfig) (Store, error) {
+	retention := Retention{MaxAge: config.Timeout(cfg.MaxAge), MaxCount: cfg.MaxCount}
+	switch cfg.Backend {
+	case BackendDisabled:
+		return disabledStore{}, nil
+	case BackendFilesystem:
+		return NewFileStore(cfg.Directory, retention)
+	case BackendSQLite:
+		return NewSQLiteStore(cfg.SQLitePath, retention)
+	default:
+		return nil, fmt.Errorf("unknown results backend %q", cfg.Backend)
+	}
+}
+
+// newRecord creates the record of a result with a new ID
+func newRecord(kind string, request, result interface{}) (*Record, error) {
+	requestData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request: %v", err)
+	}
+	resultData, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding result: %v", err)
+	}
+	now := time.Now().UTC()
+	id, err := newID(now)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{ID: id, Kind: kind, CreatedAt: now, Request: requestData, Result: resultData}, nil
+}
+
+// idTimeLayout is the time prefix of IDs, which makes them sort by creation time
+const idTimeLayout = "20060102T150405.000000Z"
+
+// idPattern matches the IDs made by newID
+var idPattern = regexp.MustCompile(`^\d{8}T\d{6}\.\d{6}Z-[0-9a-f]{16}$`)
+
+// newID returns a unique ID made of the creation time and random bytes
+func newID(now time.Time) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("error generating result ID: %v", err)
+	}
+	return now.UTC().Format(idTimeLayout) + "-" + hex.EncodeToString(random), nil
+}
+
+// validID tells whether id could have been made by newID, so that it can be used in a file name
+func validID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// idTime returns the creation time encoded in an ID
+func idTime(id string) (time.Time, error) {
+	if !validID(id) {
+		return time.Time{}, fmt.Errorf("invalid result ID %q", id)
+	}
+	return time.Parse(idTimeLayout, id[:len(idTimeLayout)])
+}
+
+// disabledStore stores nothing
+type disabledStore struct{}
+
+func (disabledStore) Save(kind string, request, result interface{}) (string, error) { return "", nil }
+func (disabledStore) Get(id string) (*Record, error)                                { return nil, ErrNotFound }
+func (disabledStore) List(filter Filter) ([]*Record, error)                         { return nil, nil }
+func (disabledStore) Close() error                                                  { return nil }
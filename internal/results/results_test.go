@@ -0,0 +1,163 @@
+package results
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stores returns a constructor for every backend, each store using its own temporary directory
+func stores() map[string]func(t *testing.T, retention Retention) Store {
+	return map[string]func(t *testing.T, retention Retention) Store{
+		BackendFilesystem: func(t *testing.T, retention Retention) Store {
+			store, err := NewFileStore(t.TempDir(), retention)
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			return store
+		},
+		BackendSQLite: func(t *testing.T, retention Retention) Store {
+			store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "results.db"), retention)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore() error = %v", err)
+			}
+			t.Cleanup(func() { store.Close() })
+			return store
+		},
+	}
+}
+
+// save stores a result, failing the test on error
+func save(t *testing.T, store Store, kind string, request, result interface{}) string {
+	t.Helper()
+	id, err := store.Save(kind, request, result)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return id
+}
+
+// listIDs returns the IDs of the records selected by filter
+func listIDs(t *testing.T, store Store, filter Filter) []string {
+	t.Helper()
+	records, err := store.List(filter)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	ids := make([]string, len(records))
+	for i, record := range records {
+		if record.Result != nil {
+			t.Errorf("List() record %s has a result", record.ID)
+		}
+		ids[i] = record.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStoreSaveGet(t *testing.T) {
+	for backend, newStore := range stores() {
+		t.Run(backend, func(t *testing.T) {
+			store := newStore(t, Retention{})
+			id := save(t, store, KindIris, map[string]int{"radius": 500}, map[string]float64{"population_total": 1234.5})
+
+			record, err := store.Get(id)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if record.ID != id || record.Kind != KindIris {
+				t.Errorf("Get() = %s %s, want %s %s", record.ID, record.Kind, id, KindIris)
+			}
+			if record.CreatedAt.IsZero() {
+				t.Error("Get() record has no creation time")
+			}
+			var result map[string]float64
+			if err := json.Unmarshal(record.Result, &result); err != nil || result["population_total"] != 1234.5 {
+				t.Errorf("Get() result = %s, want the saved result", record.Result)
+			}
+			var request map[string]int
+			if err := json.Unmarshal(record.Request, &request); err != nil || request["radius"] != 500 {
+				t.Errorf("Get() request = %s, want the saved request", record.Request)
+			}
+
+			for _, missing := range []string{"20200101T000000.000000Z-0123456789abcdef", "../results", ""} {
+				if _, err := store.Get(missing); err != ErrNotFound {
+					t.Errorf("Get(%q) error = %v, want ErrNotFound", missing, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	for backend, newStore := range stores() {
+		t.Run(backend, func(t *testing.T) {
+			store := newStore(t, Retention{})
+			search1 := save(t, store, KindSearch, "a", 1)
+			iris := save(t, store, KindIris, "b", 2)
+			search2 := save(t, store, KindSearch, "c", 3)
+
+			tests := []struct {
+				name   string
+				filter Filter
+				want   []string
+			}{
+				{"all, most recent first", Filter{}, []string{search2, iris, search1}},
+				{"kind", Filter{Kind: KindSearch}, []string{search2, search1}},
+				{"limit", Filter{Limit: 2}, []string{search2, iris}},
+				{"kind and limit", Filter{Kind: KindSearch, Limit: 1}, []string{search2}},
+				{"since", Filter{Since: time.Now().Add(time.Hour)}, []string{}},
+			}
+			for _, tt := range tests {
+				if got := listIDs(t, store, tt.filter); !equalIDs(got, tt.want) {
+					t.Errorf("List(%s) = %v, want %v", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	for backend, newStore := range stores() {
+		t.Run(backend+" max count", func(t *testing.T) {
+			store := newStore(t, Retention{MaxCount: 2})
+			first := save(t, store, KindSearch, "a", 1)
+			second := save(t, store, KindSearch, "b", 2)
+			third := save(t, store, KindSearch, "c", 3)
+
+			if got, want := listIDs(t, store, Filter{}), []string{third, second}; !equalIDs(got, want) {
+				t.Errorf("List() = %v, want %v", got, want)
+			}
+			if _, err := store.Get(first); err != ErrNotFound {
+				t.Errorf("Get() of the oldest result error = %v, want ErrNotFound", err)
+			}
+		})
+
+		t.Run(backend+" max age", func(t *testing.T) {
+			maxAge := 50 * time.Millisecond
+			store := newStore(t, Retention{MaxAge: maxAge})
+			expired := save(t, store, KindSearch, "a", 1)
+			time.Sleep(2 * maxAge)
+			recent := save(t, store, KindSearch, "b", 2)
+
+			if got, want := listIDs(t, store, Filter{}), []string{recent}; !equalIDs(got, want) {
+				t.Errorf("List() = %v, want %v", got, want)
+			}
+			if _, err := store.Get(expired); err != ErrNotFound {
+				t.Errorf("Get() of the expired result error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package results
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	// Pure Go driver, so that the binary still builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver is the database/sql driver of the sqlite backend
+const sqliteDriver = "sqlite"
+
+// sqliteSchema creates the results table on first use
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	request BLOB NOT NULL,
+	result BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_created_at ON results (created_at);`
+
+// SQLiteStore stores results in a table of an SQLite database
+type SQLiteStore struct {
+	db        *sql.DB
+	retention Retention
+}
+
+// NewSQLiteStore opens the database at path, creating it and its table if needed
+func NewSQLiteStore(path string, retention Retention) (*SQLiteStore, error) {
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening results database: %v", err)
+	}
+	// SQLite allows a single writer at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating results table: %v", err)
+	}
+	return &SQLiteStore{db: db, retention: retention}, nil
+}
+
+// Save inserts the result, then deletes the results beyond the retention limits
+func (s *SQLiteStore) Save(kind string, request, result interface{}) (string, error) {
+	record, err := newRecord(kind, request, result)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.db.Exec("INSERT INTO results (id, kind, created_at, request, result) VALUES (?, ?, ?, ?, ?)",
+		record.ID, record.Kind, record.CreatedAt.UnixNano(), []byte(record.Request), []byte(record.Result)); err != nil {
+		return "", fmt.Errorf("error inserting result: %v", err)
+	}
+
+	s.sweep()
+	return record.ID, nil
+}
+
+// Get reads a stored result
+func (s *SQLiteStore) Get(id string) (*Record, error) {
+	record := Record{ID: id}
+	var createdAt int64
+	var request, result []byte
+	err := s.db.QueryRow("SELECT kind, created_at, request, result FROM results WHERE id = ?", id).Scan(&record.Kind, &createdAt, &request, &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading result: %v", err)
+	}
+	record.CreatedAt = time.Unix(0, createdAt).UTC()
+	record.Request = request
+	record.Result = result
+	return &record, nil
+}
+
+// List reads the selected records without their results
+func (s *SQLiteStore) List(filter Filter) ([]*Record, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.Query("SELECT id, kind, created_at, request FROM results WHERE (? = '' OR kind = ?) AND created_at >= ? ORDER BY id DESC LIMIT ?",
+		filter.Kind, filter.Kind, filter.Since.UnixNano(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing results: %v", err)
+	}
+	defer rows.Close()
+
+	records := []*Record{}
+	for rows.Next() {
+		var record Record
+		var createdAt int64
+		var request []byte
+		if err := rows.Scan(&record.ID, &record.Kind, &createdAt, &request); err != nil {
+			return nil, fmt.Errorf("error listing results: %v", err)
+		}
+		record.CreatedAt = time.Unix(0, createdAt).UTC()
+		record.Request = request
+		records = append(records, &record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing results: %v", err)
+	}
+	return records, nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// sweep deletes the results older than the maximum age and the oldest ones beyond the maximum count
+func (s *SQLiteStore) sweep() {
+	if s.retention.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.retention.MaxAge).UnixNano()
+		if _, err := s.db.Exec("DELETE FROM results WHERE created_at < ?", cutoff); err != nil {
+			log.Printf("Warning: error deleting expired results: %v", err)
+		}
+	}
+	if s.retention.MaxCount > 0 {
+		if _, err := s.db.Exec("DELETE FROM results WHERE id IN (SELECT id FROM results ORDER BY id DESC LIMIT -1 OFFSET ?)", s.retention.MaxCount); err != nil {
+			log.Printf("Warning: error deleting expired results: %v", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// Types of the administrative areas a request can name instead of drawing a geometry
+const (
+	AreaCommune    = "commune"
+	AreaPostalCode = "postal_code"
+	AreaDepartment = "department"
+	AreaIris       = "iris"
+	AreaQP         = "qp"
+)
+
+// AreaTypes lists the types of administrative areas
+var AreaTypes = []string{AreaCommune, AreaPostalCode, AreaDepartment, AreaIris, AreaQP}
+
+// departmentCode returns the department of an INSEE commune code: the first two characters,
+// or three for overseas departments
+func departmentCode(communeCode string) string {
+	communeCode = padCommuneCode(communeCode)
+	if strings.HasPrefix(communeCode, "97") || strings.HasPrefix(communeCode, "98") {
+		return communeCode[:3]
+	}
+	return communeCode[:2]
+}
+
+// padCommuneCode restores the leading zeros of an INSEE commune code read as a number
+func padCommuneCode(code string) string {
+	if len(code) < 5 {
+		return strings.Repeat("0", 5-len(code)) + code
+	}
+	return code
+}
+
+// ResolveArea returns the geometry of an administrative area: a commune by INSEE code, the
+// communes of a postal code or of a department, an IRIS zone or a QP zone. Areas made of several
+// communes are merged, which can take a while for a department, so every area is resolved once
+// per dataset generation.
+func (s *CSVService) ResolveArea(ctx context.Context, areaType, code string) (*models.ResolvedArea, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	key := areaType + ":" + code
+	if area, ok := s.geoLayers.areas.Load(key); ok {
+		return area.(*models.ResolvedArea), nil
+	}
+
+	area := &models.ResolvedArea{Type: areaType, Code: code}
+	var polygons []*geom2.Geometry
+	switch areaType {
+	case AreaCommune:
+		// Codes may have lost their leading zero on either side
+		commune := s.geoLayers.communesByCode[code]
+		if commune == nil {
+			commune = s.geoLayers.communesByCode[strings.TrimLeft(code, "0")]
+		}
+		if commune == nil {
+			commune = s.geoLayers.communesByCode[padCommuneCode(code)]
+		}
+		if commune != nil {
+			area.Name = commune.CommuneName
+			polygons = append(polygons, commune.Polygon)
+		}
+	case AreaPostalCode:
+		communes := s.geoLayers.communesByPostalCode[code]
+		names := make([]string, 0, len(communes))
+		for _, commune := range communes {
+			names = append(names, commune.CommuneName)
+			polygons = append(polygons, commune.Polygon)
+		}
+		sort.Strings(names)
+		area.Name = strings.Join(names, ", ")
+	case AreaDepartment:
+		if len(code) == 1 {
+			code = "0" + code
+			area.Code = code
+		}
+		for _, commune := range s.geoLayers.communesByDepartment[code] {
+			polygons = append(polygons, commune.Polygon)
+		}
+	case AreaIris:
+		if iris := s.geoLayers.irisByCode[code]; iris != nil {
+			area.Name = iris.LAB_IRIS
+			polygons = append(polygons, iris.Polygon)
+		}
+	case AreaQP:
+		if qp := s.geoLayers.qpByCode[code]; qp != nil {
+			area.Name = qp.LibQP
+			polygons = append(polygons, qp.Polygon)
+		}
+	default:
+		return nil, newServiceError(ErrorInvalidInput, fmt.Sprintf("Area type must be one of: %s", strings.Join(AreaTypes, ", ")), nil).
+			WithDetail("type", areaType)
+	}
+
+	geometries := make([]geom2.Geometry, 0, len(polygons))
+	for _, polygon := range polygons {
+		if polygon != nil {
+			geometries = append(geometries, *polygon)
+		}
+	}
+	if len(geometries) == 0 {
+		return nil, newServiceError(ErrorNotFound, "Area not found", nil).
+			WithDetail("type", areaType).
+			WithDetail("code", code)
+	}
+
+	union, err := unionAll(ctx, geometries)
+	if err != nil {
+		return nil, err
+	}
+	geojsonStr, err := union.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding area: %v", err)
+	}
+	if area.Geometry, err = parseGeoJSONGeometry(string(geojsonStr)); err != nil {
+		return nil, fmt.Errorf("error encoding area: %v", err)
+	}
+
+	resolved, _ := s.geoLayers.areas.LoadOrStore(key, area)
+	return resolved.(*models.ResolvedArea), nil
+}
+
+// unionAll merges geometries pairwise, level by level, so that every union works on
+// geometries of similar size
+func unionAll(ctx context.Context, geometries []geom2.Geometry) (geom2.Geometry, error) {
+	for len(geometries) > 1 {
+		if ctx.Err() != nil {
+			return geom2.Geometry{}, contextError(ctx, "area").WithDetail("remaining_geometries", len(geometries))
+		}
+		merged := make([]geom2.Geometry, 0, (len(geometries)+1)/2)
+		for i := 0; i < len(geometries); i += 2 {
+			if i+1 == len(geometries) {
+				merged = append(merged, geometries[i])
+				continue
+			}
+			union, err := geom2.Union(geometries[i], geometries[i+1])
+			if err != nil {
+				return geom2.Geometry{}, fmt.Errorf("error merging area polygons: %v", err)
+			}
+			merged = append(merged, union)
+		}
+		geometries = merged
+	}
+	return geometries[0], nil
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/metrics"
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// businessLoadProgressInterval is the number of CSV rows between two progress log lines
+const businessLoadProgressInterval = 1000000
+
+// contextCheckInterval is the number of CSV rows read between two checks of the context
+const contextCheckInterval = 10000
+
+// stringColumn stores many strings in a single byte buffer to avoid one allocation per value
+type stringColumn struct {
+	data    []byte
+	offsets []uint32
+}
+
+// append adds a value at the end of the column
+func (c *stringColumn) append(value string) error {
+	if len(c.data)+len(value) > math.MaxUint32 {
+		return fmt.Errorf("string column exceeds %d bytes", uint32(math.MaxUint32))
+	}
+	if len(c.offsets) == 0 {
+		c.offsets = append(c.offsets, 0)
+	}
+	c.data = append(c.data, value...)
+	c.offsets = append(c.offsets, uint32(len(c.data)))
+	return nil
+}
+
+// get returns the value stored at the given row
+func (c *stringColumn) get(row int) string {
+	return string(c.data[c.offsets[row]:c.offsets[row+1]])
+}
+
+// size returns the number of bytes used by the column
+func (c *stringColumn) size() int {
+	return cap(c.data) + cap(c.offsets)*4
+}
+
+// trim releases the spare capacity left by append
+func (c *stringColumn) trim() {
+	c.data = append([]byte(nil), c.data...)
+	c.offsets = append([]uint32(nil), c.offsets...)
+}
+
+// BusinessStore keeps every establishment of the business CSV file in memory.
+// Values are stored column by column and rows are indexed by NAF code, so a
+// search only touches the rows of the requested NAF codes. Each NAF code also
+// gets its own R-tree so polygon searches skip businesses outside the envelope.
+type BusinessStore struct {
+	names      stringColumn
+	sirets     stringColumn
+	addresses  stringColumn
+	latitudes  []float64
+	longitudes []float64
+	nafCodes   []uint16
+	nafLabels  []string
+	rowsByNAF  map[string][]int32
+	// One spatial index per NAF code over the rows of rowsByNAF
+	indexesByNAF map[string]*models.SpatialIndex
+	// Number of CSV rows read, including the skipped ones
+	rowsRead int
+}
+
+// NewBusinessStore loads the business CSV file into memory, stopping early when ctx is done.
+// Columns are located in the header through schema.
+func NewBusinessStore(ctx context.Context, filePath string, schema config.Schema) (*BusinessStore, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening CSV file: %v", err)
+	}
+	defer file.Close()
+	return readBusinessStore(ctx, file, filePath, schema)
+}
+
+// readBusinessStore loads the business CSV data read from r, filePath naming it in errors and logs
+func readBusinessStore(ctx context.Context, r io.Reader, filePath string, schema config.Schema) (*BusinessStore, error) {
+	startTime := time.Now()
+
+	// Use buffered reader for better performance
+	bufReader := bufio.NewReaderSize(r, 1<<20)
+	reader := csv.NewReader(bufReader)
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true // Reuse record slice for better memory usage
+
+	// Locate the columns in the header once, rows are read by position
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV header: %v", err)
+	}
+	cols, err := loadColumns(DatasetBusinesses, filePath, header, schema)
+	if err != nil {
+		return nil, err
+	}
+	nafColumn := cols.index("naf_code")
+	nameColumn := cols.index("name")
+	siretColumn := cols.index("siret")
+	longitudeColumn := cols.index("longitude")
+	latitudeColumn := cols.index("latitude")
+	addressColumns := []int{
+		cols.index("address_complement"),
+		cols.index("street_number"),
+		cols.index("street_type"),
+		cols.index("street_name"),
+		cols.index("postal_code"),
+		cols.index("city"),
+	}
+	addressParts := make([]string, len(addressColumns))
+
+	store := &BusinessStore{
+		rowsByNAF: make(map[string][]int32),
+	}
+	nafIndexes := make(map[string]uint16)
+
+	// Pre-allocate address builder with reasonable capacity
+	var address strings.Builder
+	address.Grow(200)
+
+	rowsRead := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("loading businesses interrupted after %d rows: %w", rowsRead, err)
+			}
+		}
+		if rowsRead%businessLoadProgressInterval == 0 {
+			log.Printf("Business store: %d rows read, %d businesses kept (%v)", rowsRead, store.Len(), time.Since(startTime).Round(time.Second))
+		}
+		if err != nil {
+			// Malformed rows are skipped, other errors would be returned again by every read
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("error reading CSV file after %d rows: %v", rowsRead, err)
+			}
+			continue
+		}
+
+		recordNAFCode := valueAt(record, nafColumn)
+		if recordNAFCode == "" {
+			continue
+		}
+
+		// Parse business name
+		businessName := valueAt(record, nameColumn)
+		if businessName == "" {
+			continue
+		}
+
+		if len(businessName) < 9 {
+			businessName = "0" + businessName
+		}
+
+		// Parse siret
+		siret := valueAt(record, siretColumn)
+		if siret == "" {
+			continue
+		}
+
+		if len(siret) < 14 {
+			siret = "0" + siret
+		}
+
+		// Parse coordinates
+		longitude, err := strconv.ParseFloat(valueAt(record, longitudeColumn), 64)
+		if err != nil {
+			continue
+		}
+		latitude, err := strconv.ParseFloat(valueAt(record, latitudeColumn), 64)
+		if err != nil {
+			continue
+		}
+
+		// Reset address builder
+		address.Reset()
+
+		for i, column := range addressColumns {
+			addressParts[i] = valueAt(record, column)
+		}
+
+		for i, part := range addressParts {
+			if part != "" {
+				if i > 0 && addressParts[i-1] != "" {
+					address.WriteString(" ")
+				}
+				address.WriteString(part)
+			}
+		}
+
+		nafIndex, exists := nafIndexes[recordNAFCode]
+		if !exists {
+			if len(store.nafLabels) > math.MaxUint16 {
+				return nil, fmt.Errorf("too many distinct NAF codes in %s", filePath)
+			}
+			nafIndex = uint16(len(store.nafLabels))
+			// Clone the code so the map key does not retain the whole CSV line
+			label := strings.Clone(recordNAFCode)
+			nafIndexes[label] = nafIndex
+			store.nafLabels = append(store.nafLabels, label)
+		}
+
+		row := int32(store.Len())
+		if err := store.names.append(businessName); err != nil {
+			return nil, fmt.Errorf("error storing business name: %v", err)
+		}
+		if err := store.sirets.append(siret); err != nil {
+			return nil, fmt.Errorf("error storing business siret: %v", err)
+		}
+		if err := store.addresses.append(address.String()); err != nil {
+			return nil, fmt.Errorf("error storing business address: %v", err)
+		}
+		store.latitudes = append(store.latitudes, latitude)
+		store.longitudes = append(store.longitudes, longitude)
+		store.nafCodes = append(store.nafCodes, nafIndex)
+		label := store.nafLabels[nafIndex]
+		store.rowsByNAF[label] = append(store.rowsByNAF[label], row)
+	}
+
+	store.rowsRead = rowsRead
+	store.trim()
+	store.buildIndexes()
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	log.Printf("Business store loaded: %d businesses, %d NAF codes, %d rows read in %v",
+		store.Len(), len(store.nafLabels), rowsRead, time.Since(startTime).Round(time.Millisecond))
+	log.Printf("Business store memory: %.1f MiB in columns, %.1f MiB heap in use",
+		float64(store.SizeBytes())/(1<<20), float64(memStats.HeapInuse)/(1<<20))
+
+	return store, nil
+}
+
+// trim releases the spare capacity left after loading
+func (s *BusinessStore) trim() {
+	s.names.trim()
+	s.sirets.trim()
+	s.addresses.trim()
+	s.latitudes = append([]float64(nil), s.latitudes...)
+	s.longitudes = append([]float64(nil), s.longitudes...)
+	s.nafCodes = append([]uint16(nil), s.nafCodes...)
+	for code, rows := range s.rowsByNAF {
+		s.rowsByNAF[code] = append([]int32(nil), rows...)
+	}
+}
+
+// buildIndexes builds the spatial index of every NAF code
+func (s *BusinessStore) buildIndexes() {
+	startTime := time.Now()
+	s.indexesByNAF = make(map[string]*models.SpatialIndex, len(s.rowsByNAF))
+	for code, rows := range s.rowsByNAF {
+		xs := make([]float64, len(rows))
+		ys := make([]float64, len(rows))
+		for i, row := range rows {
+			xs[i] = s.longitudes[row]
+			ys[i] = s.latitudes[row]
+		}
+		s.indexesByNAF[code] = models.NewPointIndex(xs, ys)
+	}
+	log.Printf("Business store: spatial indexes built for %d NAF codes in %v", len(s.indexesByNAF), time.Since(startTime).Round(time.Millisecond))
+}
+
+// Len returns the number of businesses in the store
+func (s *BusinessStore) Len() int {
+	return len(s.latitudes)
+}
+
+// SkippedRows returns the number of CSV rows that could not be parsed
+func (s *BusinessStore) SkippedRows() int {
+	return s.rowsRead - s.Len()
+}
+
+// SizeBytes returns the approximate memory footprint of the stored columns
+func (s *BusinessStore) SizeBytes() int {
+	size := s.names.size() + s.sirets.size() + s.addresses.size()
+	size += cap(s.latitudes)*8 + cap(s.longitudes)*8 + cap(s.nafCodes)*2
+	for _, rows := range s.rowsByNAF {
+		size += cap(rows) * 4
+	}
+	for _, index := range s.indexesByNAF {
+		size += index.SizeBytes()
+	}
+	return size
+}
+
+// business builds the Business stored at the given row
+func (s *BusinessStore) business(row int32) *models.Business {
+	return &models.Business{
+		Name:      s.names.get(int(row)),
+		Siret:     s.sirets.get(int(row)),
+		NAFCode:   s.nafLabels[s.nafCodes[row]],
+		Latitude:  s.latitudes[row],
+		Longitude: s.longitudes[row],
+		Address:   s.addresses.get(int(row)),
+	}
+}
+
+// BusinessesByNAF returns the businesses having any of the given NAF codes, grouped by code
+// in the order of nafCodes
+func (s *BusinessStore) BusinessesByNAF(nafCodes []string) []*models.Business {
+	// Ignore duplicate codes so a business is never returned twice
+	seen := make(map[string]bool, len(nafCodes))
+	codes := make([]string, 0, len(nafCodes))
+	count := 0
+	for _, code := range nafCodes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+		count += len(s.rowsByNAF[code])
+	}
+
+	businesses := make([]*models.Business, 0, count)
+	for _, code := range codes {
+		for _, row := range s.rowsByNAF[code] {
+			businesses = append(businesses, s.business(row))
+		}
+	}
+	return businesses
+}
+
+// SearchByNAF returns the businesses having any of the given NAF codes that lie within the geometry
+func (s *BusinessStore) SearchByNAF(geometry geom2.Geometry, nafCodes []string) []*models.Business {
+	polygons := models.NewPolygonSet(geometry)
+	if polygons.IsEmpty() {
+		return nil
+	}
+	return s.SearchByNAFWithin(polygons.Envelope(), polygons.ContainsPoint, nafCodes)
+}
+
+// SearchByNAFWithin returns the businesses having any of the given NAF codes
+// that lie inside the envelope and for which contains returns true
+func (s *BusinessStore) SearchByNAFWithin(envelope models.Envelope, contains func(lng, lat float64) bool, nafCodes []string) []*models.Business {
+	// Count the businesses tested against the area, they are the ones inside the envelope
+	scanned := 0
+	counting := func(lng, lat float64) bool {
+		scanned++
+		return contains(lng, lat)
+	}
+
+	seen := make(map[string]bool, len(nafCodes))
+	var businesses []*models.Business
+	for _, code := range nafCodes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		index, exists := s.indexesByNAF[code]
+		if !exists {
+			continue
+		}
+		rows := s.rowsByNAF[code]
+		for _, position := range index.QueryWithin(envelope, counting) {
+			businesses = append(businesses, s.business(rows[position]))
+		}
+	}
+
+	metrics.BusinessesScanned.Add(float64(scanned))
+	metrics.BusinessesMatched.Add(float64(len(businesses)))
+	return businesses
+}
+
+// EachByNAFWithin calls visit with every business having any of the given NAF codes that lies
+// inside the envelope and for which contains returns true, without holding them in memory.
+// It stops at the first error returned by visit and returns it.
+func (s *BusinessStore) EachByNAFWithin(envelope models.Envelope, contains func(lng, lat float64) bool, nafCodes []string, visit func(business *models.Business) error) error {
+	scanned := 0
+	matched := 0
+	counting := func(lng, lat float64) bool {
+		scanned++
+		return contains(lng, lat)
+	}
+	defer func() {
+		metrics.BusinessesScanned.Add(float64(scanned))
+		metrics.BusinessesMatched.Add(float64(matched))
+	}()
+
+	seen := make(map[string]bool, len(nafCodes))
+	for _, code := range nafCodes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		index, exists := s.indexesByNAF[code]
+		if !exists {
+			continue
+		}
+		rows := s.rowsByNAF[code]
+		var err error
+		index.VisitWithin(envelope, counting, func(position int) bool {
+			matched++
+			err = visit(s.business(rows[position]))
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+const businessCSV = `latitude,longitude,siret,denominationUniteLegale,activitePrincipaleEtablissement,numeroVoieEtablissement,typeVoieEtablissement,libelleVoieEtablissement,codePostalEtablissement,libelleCommuneEtablissement
+48.8566,2.3522,12345678900011,BOULANGERIE DU CENTRE,10.71C,12,RUE,DE RIVOLI,75001,PARIS
+45.7640,4.8357,12345678900012,BOULANGERIE DE LYON,10.71C,,,,69001,LYON
+48.8600,2.3400,12345678900013,PHARMACIE DU LOUVRE,47.73Z,1,PLACE,DU LOUVRE,75001,PARIS
+48.8700,,12345678900014,PHARMACIE SANS COORDONNEES,47.73Z,,,,,
+48.8500,2.3500,12345678900015,SANS ACTIVITE,,,,,,
+48.8550,2.3600,12345678900016,GARAGE DE PARIS,45.20A,,,,,
+`
+
+// loadBusinesses loads content as the business CSV file
+func loadBusinesses(t *testing.T, content string) *BusinessStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "businesses.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	store, err := NewBusinessStore(context.Background(), path, config.GetSchemaConfig()[DatasetBusinesses])
+	if err != nil {
+		t.Fatalf("NewBusinessStore() error = %v", err)
+	}
+	return store
+}
+
+// sirets returns the siret of every business
+func sirets(businesses []*models.Business) []string {
+	result := make([]string, len(businesses))
+	for i, business := range businesses {
+		result[i] = business.Siret
+	}
+	return result
+}
+
+func TestBusinessStoreLoad(t *testing.T) {
+	store := loadBusinesses(t, businessCSV)
+
+	if store.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", store.Len())
+	}
+	// The business without longitude and the one without NAF code are skipped
+	if store.SkippedRows() != 2 {
+		t.Errorf("SkippedRows() = %d, want 2", store.SkippedRows())
+	}
+
+	businesses := store.BusinessesByNAF([]string{"47.73Z"})
+	if len(businesses) != 1 {
+		t.Fatalf("BusinessesByNAF(47.73Z) = %d businesses, want 1", len(businesses))
+	}
+	want := models.Business{
+		Name:      "PHARMACIE DU LOUVRE",
+		Siret:     "12345678900013",
+		NAFCode:   "47.73Z",
+		Latitude:  48.86,
+		Longitude: 2.34,
+		Address:   "1 PLACE DU LOUVRE 75001 PARIS",
+	}
+	if got := businesses[0]; got.Name != want.Name || got.Siret != want.Siret || got.NAFCode != want.NAFCode ||
+		got.Latitude != want.Latitude || got.Longitude != want.Longitude || got.Address != want.Address {
+		t.Errorf("business = %+v, want %+v", *got, want)
+	}
+}
+
+func TestBusinessesByNAF(t *testing.T) {
+	store := loadBusinesses(t, businessCSV)
+
+	tests := []struct {
+		name     string
+		nafCodes []string
+		want     []string
+	}{
+		{"request order", []string{"45.20A", "10.71C"}, []string{"12345678900016", "12345678900011", "12345678900012"}},
+		{"file order within a code", []string{"10.71C"}, []string{"12345678900011", "12345678900012"}},
+		{"duplicate code", []string{"47.73Z", "45.20A", "47.73Z"}, []string{"12345678900013", "12345678900016"}},
+		{"unknown code", []string{"99.99Z"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sirets(store.BusinessesByNAF(tt.nafCodes))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("BusinessesByNAF(%v) = %v, want %v", tt.nafCodes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusinessStoreSearchByNAF(t *testing.T) {
+	store := loadBusinesses(t, businessCSV)
+	// Central Paris, which leaves out the Lyon bakery
+	paris, err := geom2.UnmarshalWKT("POLYGON((2.30 48.83,2.40 48.83,2.40 48.88,2.30 48.88,2.30 48.83))")
+	if err != nil {
+		t.Fatalf("UnmarshalWKT() error = %v", err)
+	}
+
+	got := sirets(store.SearchByNAF(paris, []string{"10.71C", "47.73Z"}))
+	want := []string{"12345678900011", "12345678900013"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("SearchByNAF() = %v, want %v", got, want)
+	}
+}
+
+func TestBusinessStoreReadError(t *testing.T) {
+	readErr := errors.New("device error")
+	// The rows are followed by a read error instead of the end of the file
+	reader := io.MultiReader(strings.NewReader(businessCSV), iotest.ErrReader(readErr))
+
+	_, err := readBusinessStore(context.Background(), reader, "businesses.csv", config.GetSchemaConfig()[DatasetBusinesses])
+	if err == nil || !strings.Contains(err.Error(), readErr.Error()) {
+		t.Errorf("readBusinessStore() error = %v, want the read error", err)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"csv-processor/internal/config"
+)
+
+// columns locates the fields of a schema in the header of a CSV file
+type columns struct {
+	indexes map[string]int
+}
+
+// MissingColumn is a required column that a CSV file does not have
+type MissingColumn struct {
+	Dataset string
+	Path    string
+	Field   string
+	Header  string
+}
+
+// SchemaError lists the required columns missing from the CSV files
+type SchemaError struct {
+	Missing []MissingColumn
+}
+
+// Error reports the missing columns, one line per dataset
+func (e *SchemaError) Error() string {
+	var report strings.Builder
+	report.WriteString("missing required columns, set their headers under \"schemas\" in " + config.ConfigFile + ":")
+	for i, column := range e.Missing {
+		if i == 0 || column.Dataset != e.Missing[i-1].Dataset {
+			fmt.Fprintf(&report, "\n  %s (%s):", column.Dataset, column.Path)
+		} else {
+			report.WriteString(",")
+		}
+		fmt.Fprintf(&report, " %s (header %q)", column.Field, column.Header)
+	}
+	return report.String()
+}
+
+// normalizeHeader returns the form under which headers are compared
+func normalizeHeader(header string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+}
+
+// resolveColumns locates the fields of a schema in a CSV header. It returns the required
+// columns the header does not have, sorted by field.
+func resolveColumns(dataset, path string, header []string, schema config.Schema) (*columns, []MissingColumn) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		// The first of duplicate headers is used
+		if _, exists := positions[normalizeHeader(name)]; !exists {
+			positions[normalizeHeader(name)] = i
+		}
+	}
+
+	cols := &columns{indexes: make(map[string]int, len(schema))}
+	var missing []MissingColumn
+	for field, column := range schema {
+		if index, found := positions[normalizeHeader(column.Header)]; found {
+			cols.indexes[field] = index
+		} else if !column.Optional {
+			missing = append(missing, MissingColumn{Dataset: dataset, Path: path, Field: field, Header: column.Header})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Field < missing[j].Field
+	})
+	return cols, missing
+}
+
+// loadColumns locates the fields of a schema in a CSV header, failing when required columns are missing
+func loadColumns(dataset, path string, header []string, schema config.Schema) (*columns, error) {
+	cols, missing := resolveColumns(dataset, path, header, schema)
+	if len(missing) > 0 {
+		return nil, &SchemaError{Missing: missing}
+	}
+	return cols, nil
+}
+
+// index returns the position of a field, or -1 when its optional column is absent
+func (c *columns) index(field string) int {
+	if index, found := c.indexes[field]; found {
+		return index
+	}
+	return -1
+}
+
+// get returns the value of a field in a record, empty when the column is absent
+func (c *columns) get(record []string, field string) string {
+	return valueAt(record, c.index(field))
+}
+
+// valueAt returns the value at a position of a record, empty for a negative position or a short record
+func valueAt(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return record[index]
+}
+
+// checkSchemas reads the header of every configured CSV file and reports all the required columns
+// they miss at once, before any file is loaded. Files that cannot be read are left to their loaders.
+func checkSchemas(csvConfig config.CSVConfig, schemas config.SchemaConfig) error {
+	var missing []MissingColumn
+	for _, dataset := range configuredDatasets(csvConfig) {
+		path := config.GetDataFilePath(dataset.fileName)
+		header, err := readHeader(path, dataset.comma)
+		if err != nil {
+			continue
+		}
+		_, datasetMissing := resolveColumns(dataset.name, path, header, schemas[dataset.name])
+		missing = append(missing, datasetMissing...)
+	}
+	if len(missing) > 0 {
+		return &SchemaError{Missing: missing}
+	}
+	return nil
+}
+
+// readHeader returns the first line of a CSV file
+func readHeader(path string, comma rune) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comma = comma
+	reader.LazyQuotes = true
+	return reader.Read()
+}
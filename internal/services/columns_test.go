@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"csv-processor/internal/config"
+)
+
+func TestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []string
+		schema  config.Schema
+		indexes map[string]int
+		missing []string
+	}{
+		{
+			name:    "case, spaces and byte order mark",
+			header:  []string{"\ufeffSiret", "  NAF_Code ", "name"},
+			schema:  config.Schema{"siret": {Header: "siret"}, "naf": {Header: "naf_code"}, "name": {Header: "NAME"}},
+			indexes: map[string]int{"siret": 0, "naf": 1, "name": 2},
+		},
+		{
+			name:    "duplicate headers",
+			header:  []string{"code", "label", "code"},
+			schema:  config.Schema{"code": {Header: "code"}},
+			indexes: map[string]int{"code": 0},
+		},
+		{
+			name:    "reordered header",
+			header:  []string{"polygon", "Commune", "Lib_QP", "Code_QP", "id"},
+			schema:  config.GetSchemaConfig()[DatasetQP],
+			indexes: map[string]int{"id": 4, "code_qp": 3, "label": 2, "commune": 1, "polygon": 0},
+		},
+		{
+			name:    "missing optional column",
+			header:  []string{"code"},
+			schema:  config.Schema{"code": {Header: "code"}, "income": {Header: "MED20", Optional: true}},
+			indexes: map[string]int{"code": 0, "income": -1},
+		},
+		{
+			name:    "missing required columns",
+			header:  []string{"code"},
+			schema:  config.Schema{"code": {Header: "code"}, "population": {Header: "POP"}, "area": {Header: "AREA"}},
+			indexes: map[string]int{"code": 0, "population": -1, "area": -1},
+			missing: []string{"area", "population"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, missing := resolveColumns("dataset", "data.csv", tt.header, tt.schema)
+			for field, want := range tt.indexes {
+				if got := cols.index(field); got != want {
+					t.Errorf("index(%q) = %d, want %d", field, got, want)
+				}
+			}
+			var missingFields []string
+			for _, column := range missing {
+				missingFields = append(missingFields, column.Field)
+			}
+			if !reflect.DeepEqual(missingFields, tt.missing) {
+				t.Errorf("missing = %v, want %v", missingFields, tt.missing)
+			}
+		})
+	}
+}
+
+func TestColumnsGet(t *testing.T) {
+	cols, _ := resolveColumns("dataset", "data.csv", []string{"code", "label"},
+		config.Schema{"label": {Header: "label"}, "income": {Header: "MED20", Optional: true}})
+	record := []string{"75056"}
+	if got := cols.get(record, "label"); got != "" {
+		t.Errorf("get() of a short record = %q, want empty", got)
+	}
+	if got := cols.get([]string{"75056", "Paris"}, "label"); got != "Paris" {
+		t.Errorf("get() = %q, want Paris", got)
+	}
+	if got := cols.get(record, "income"); got != "" {
+		t.Errorf("get() of an absent column = %q, want empty", got)
+	}
+}
+
+func TestSchemaErrorGroupsDatasets(t *testing.T) {
+	err := &SchemaError{Missing: []MissingColumn{
+		{Dataset: "iris_data", Path: "data/iris.csv", Field: "area", Header: "AREA"},
+		{Dataset: "iris_data", Path: "data/iris.csv", Field: "polygon", Header: "POLYGON"},
+		{Dataset: "qp_data", Path: "data/qp.csv", Field: "code_qp", Header: "Code_QP"},
+	}}
+	want := `missing required columns, set their headers under "schemas" in config.json:` +
+		"\n  iris_data (data/iris.csv): area (header \"AREA\"), polygon (header \"POLYGON\")" +
+		"\n  qp_data (data/qp.csv): code_qp (header \"Code_QP\")"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// writeDataFile writes a CSV file to the data directory
+func writeDataFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(config.GetDataFilePath(name), []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+}
+
+func TestCheckSchemas(t *testing.T) {
+	dataDir := config.DataDir
+	config.DataDir = t.TempDir()
+	t.Cleanup(func() { config.DataDir = dataDir })
+	csvConfig := config.CSVConfig{QPData: "qp.csv", CommuneData: "communes.csv", IrisData: "missing.csv"}
+
+	t.Run("reordered header", func(t *testing.T) {
+		writeDataFile(t, "qp.csv", "polygon;Commune;Lib_QP;Code_QP;id\n")
+		writeDataFile(t, "communes.csv", "LIBCOM;AREA;CODE_POSTAL;POLYGON;P20_POP;COM\n")
+		if err := checkSchemas(csvConfig, config.GetSchemaConfig()); err != nil {
+			t.Errorf("checkSchemas() error = %v", err)
+		}
+	})
+
+	t.Run("missing headers", func(t *testing.T) {
+		writeDataFile(t, "qp.csv", "id;Lib_QP;Commune;polygon\n")
+		writeDataFile(t, "communes.csv", "COM;POLYGON;CODE_POSTAL;LIBCOM\n")
+		err := checkSchemas(csvConfig, config.GetSchemaConfig())
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatalf("checkSchemas() error = %v, want a SchemaError", err)
+		}
+		var missing []string
+		for _, column := range schemaErr.Missing {
+			missing = append(missing, column.Dataset+"."+column.Field)
+		}
+		want := []string{"commune_data.population", "commune_data.surface_area", "qp_data.code_qp"}
+		if !reflect.DeepEqual(missing, want) {
+			t.Errorf("missing = %v, want %v", missing, want)
+		}
+		// A single error reports every dataset
+		for _, header := range []string{"P20_POP", "AREA", "Code_QP"} {
+			if !strings.Contains(err.Error(), header) {
+				t.Errorf("error %q does not name %s", err, header)
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/csv"
 	"math"
 	"os"
@@ -16,20 +17,27 @@ import (
 // CompetitionService handles competition data requests
 type CompetitionService struct {
 	competitionData map[string]*models.BusinessData
+	filePath        string
+	schema          config.Schema
 }
 
-func NewCompetitionService() (*CompetitionService, error) {
+func NewCompetitionService(filePath string, schema config.Schema) (*CompetitionService, error) {
 	service := &CompetitionService{
 		competitionData: make(map[string]*models.BusinessData),
+		filePath:        filePath,
+		schema:          schema,
 	}
 	return service, nil
 }
 
-func (s *CompetitionService) doLoadCompetitionData(businesses []*models.Business) error {
-	if err := s.loadCompetitionData(businesses); err != nil {
-		return err
+func (s *CompetitionService) doLoadCompetitionData(ctx context.Context, businesses []*models.Business) (loadStats, error) {
+	startTime := time.Now()
+	stats, err := s.loadCompetitionData(ctx, businesses)
+	if err != nil {
+		return stats, err
 	}
-	return nil
+	stats.duration = time.Since(startTime)
+	return stats, nil
 }
 
 func getLatitudeAndLongitude(geolocalisation string) (float64, float64) {
@@ -42,11 +50,12 @@ func getLatitudeAndLongitude(geolocalisation string) (float64, float64) {
 	return latitude, longitude
 }
 
-func (s *CompetitionService) loadCompetitionData(businesses []*models.Business) error {
-	csvConfig := config.GetCSVConfig()
-	file, err := os.Open(config.GetDataFilePath(csvConfig.CompetitionData))
+// loadCompetitionData scans the competition CSV file for the given businesses and returns its row counts
+func (s *CompetitionService) loadCompetitionData(ctx context.Context, businesses []*models.Business) (loadStats, error) {
+	var stats loadStats
+	file, err := os.Open(s.filePath)
 	if err != nil {
-		return err
+		return stats, err
 	}
 	defer file.Close()
 
@@ -55,7 +64,11 @@ func (s *CompetitionService) loadCompetitionData(businesses []*models.Business)
 
 	header, err := reader.Read()
 	if err != nil {
-		return err
+		return stats, err
+	}
+	cols, err := loadColumns(DatasetCompetition, s.filePath, header, s.schema)
+	if err != nil {
+		return stats, err
 	}
 
 	// Create a map of sirets for faster lookup
@@ -63,19 +76,31 @@ func (s *CompetitionService) loadCompetitionData(businesses []*models.Business)
 	for _, business := range businesses {
 		sirets[business.Siret] = true
 	}
+	totalSirets := len(sirets)
 
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err != nil {
 			break
 		}
 
+		// Stop scanning the file when the client is gone or the deadline expired
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 && ctx.Err() != nil {
+			return stats, contextError(ctx, "competition").
+				WithDetail("rows_read", rowsRead).
+				WithDetail("businesses_found", totalSirets-len(sirets))
+		}
+
 		if len(record) < len(header) {
+			stats.skipped++
 			continue
 		}
+		stats.rows++
 
-		siren := record[1]
-		nic := record[2]
+		siren := cols.get(record, "siren")
+		nic := cols.get(record, "nic")
 		siret := siren + nic
 
 		// Skip if siret not in businesses
@@ -89,7 +114,7 @@ func (s *CompetitionService) loadCompetitionData(businesses []*models.Business)
 		// Check if we need to update existing data
 		existingData, exists := s.competitionData[siret]
 		if exists {
-			publicationDate := record[18]
+			publicationDate := cols.get(record, "publication_date")
 			const layout = "2006-01-02"
 			publicationDateParsed, err := time.Parse(layout, publicationDate)
 			if err != nil {
@@ -104,57 +129,69 @@ func (s *CompetitionService) loadCompetitionData(businesses []*models.Business)
 		}
 
 		// Parse geolocation
-		latitude, longitude := getLatitudeAndLongitude(record[17])
+		latitude, longitude := getLatitudeAndLongitude(cols.get(record, "geolocation"))
 
 		// Create new business data
 		businessData := &models.BusinessData{
-			Name:               record[0],
+			Name:               cols.get(record, "name"),
 			Siren:             siren,
 			NIC:               nic,
-			LegalStatus:       record[3],
-			CodeAPE:           record[4],
-			LabelAPE:          record[5],
-			Address:           record[6],
-			PostalCode:        record[7],
-			City:              record[8],
-			NumDepartment:     record[9],
-			Department:        record[10],
-			Region:            record[11],
-			CodeGreffe:        record[12],
-			Greffe:            record[13],
-			RegistrationDate:  record[14],
-			DeregistrationDate: record[15],
-			Status:            record[16],
+			LegalStatus:       cols.get(record, "legal_status"),
+			CodeAPE:           cols.get(record, "code_ape"),
+			LabelAPE:          cols.get(record, "label_ape"),
+			Address:           cols.get(record, "address"),
+			PostalCode:        cols.get(record, "postal_code"),
+			City:              cols.get(record, "city"),
+			NumDepartment:     cols.get(record, "num_department"),
+			Department:        cols.get(record, "department"),
+			Region:            cols.get(record, "region"),
+			CodeGreffe:        cols.get(record, "code_greffe"),
+			Greffe:            cols.get(record, "greffe"),
+			RegistrationDate:  cols.get(record, "registration_date"),
+			DeregistrationDate: cols.get(record, "deregistration_date"),
+			Status:            cols.get(record, "status"),
 			Latitude:          latitude,
 			Longitude:         longitude,
-			PublicationDate:   record[18],
-			Millesime1:        record[19],
-			DateCloseEx1:      record[20],
-			DurationEx1:       record[21],
-			CA1:               record[22],
-			Result1:           record[23],
-			Employees1:        record[24],
-			Millesime2:        record[25],
-			DateCloseEx2:      record[26],
-			DurationEx2:       record[27],
-			CA2:               record[28],
-			Result2:           record[29],
-			Employees2:        record[30],
-			Millesime3:        record[31],
-			DateCloseEx3:      record[32],
-			DurationEx3:       record[33],
-			CA3:               record[34],
-			Result3:           record[35],
-			Employees3:        record[36],
-			RangeCA1:          record[37],
-			RangeCA2:          record[38],
-			RangeCA3:          record[39],
+			PublicationDate:   cols.get(record, "publication_date"),
+			Millesime1:        cols.get(record, "millesime_1"),
+			DateCloseEx1:      cols.get(record, "date_close_1"),
+			DurationEx1:       cols.get(record, "duration_1"),
+			CA1:               cols.get(record, "ca_1"),
+			Result1:           cols.get(record, "result_1"),
+			Employees1:        cols.get(record, "employees_1"),
+			Millesime2:        cols.get(record, "millesime_2"),
+			DateCloseEx2:      cols.get(record, "date_close_2"),
+			DurationEx2:       cols.get(record, "duration_2"),
+			CA2:               cols.get(record, "ca_2"),
+			Result2:           cols.get(record, "result_2"),
+			Employees2:        cols.get(record, "employees_2"),
+			Millesime3:        cols.get(record, "millesime_3"),
+			DateCloseEx3:      cols.get(record, "date_close_3"),
+			DurationEx3:       cols.get(record, "duration_3"),
+			CA3:               cols.get(record, "ca_3"),
+			Result3:           cols.get(record, "result_3"),
+			Employees3:        cols.get(record, "employees_3"),
+			RangeCA1:          cols.get(record, "range_ca_1"),
+			RangeCA2:          cols.get(record, "range_ca_2"),
+			RangeCA3:          cols.get(record, "range_ca_3"),
 		}
 
 		s.competitionData[siret] = businessData
 	}
 
-	return nil
+	return stats, nil
+}
+
+// GetCompetitorDetails pairs every business with the competition data read for it, if any
+func (s *CompetitionService) GetCompetitorDetails(businesses []*models.Business) []models.CompetitorDetails {
+	details := make([]models.CompetitorDetails, 0, len(businesses))
+	for _, business := range businesses {
+		details = append(details, models.CompetitorDetails{
+			Business: business,
+			Data:     s.competitionData[business.Siret],
+		})
+	}
+	return details
 }
 
 // Helper functions for processing business data
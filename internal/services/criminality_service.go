@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"csv-processor/internal/config"
 	"csv-processor/internal/models"
@@ -14,28 +16,58 @@ import (
 type CriminalityService struct {
 	communeCrimes     map[string]map[string]float64 // map[commune_code]map[crime_type]rate
 	departmentCrimes  map[string]map[string]float64 // map[department_code]map[crime_type]rate
+	// Rows skipped while parsing each file
+	communeCrimesSkipped    int
+	departmentCrimesSkipped int
+	// Statistics of the load of each file, published with the generation
+	communeCrimesStats    loadStats
+	departmentCrimesStats loadStats
+	csvConfig               config.CSVConfig // file names of the generation being loaded
+	schemas                 config.SchemaConfig // key columns of these files
 }
 
-func NewCriminalityService() (*CriminalityService, error) {
+func NewCriminalityService(ctx context.Context, csvConfig config.CSVConfig, schemas config.SchemaConfig) (*CriminalityService, error) {
 	service := &CriminalityService{
 		communeCrimes:     make(map[string]map[string]float64),
 		departmentCrimes:  make(map[string]map[string]float64),
+		csvConfig:         csvConfig,
+		schemas:           schemas,
 	}
 
-	if err := service.loadCommuneCrimes(); err != nil {
+	startTime := time.Now()
+	if err := service.loadCommuneCrimes(ctx); err != nil {
 		return nil, fmt.Errorf("failed to load commune crimes: %w", err)
 	}
+	service.communeCrimesStats = loadStats{rows: len(service.communeCrimes), skipped: service.communeCrimesSkipped, duration: time.Since(startTime)}
 
-	if err := service.loadDepartmentCrimes(); err != nil {
+	startTime = time.Now()
+	if err := service.loadDepartmentCrimes(ctx); err != nil {
 		return nil, fmt.Errorf("failed to load department crimes: %w", err)
 	}
+	service.departmentCrimesStats = loadStats{rows: len(service.departmentCrimes), skipped: service.departmentCrimesSkipped, duration: time.Since(startTime)}
 
 	return service, nil
 }
 
-func (s *CriminalityService) loadCommuneCrimes() error {
-	csvConfig := config.GetCSVConfig()
-	file, err := os.Open(config.GetDataFilePath(csvConfig.CommuneCrimes))
+// crimeTypeColumns returns the positions of the crime types of a header: every column
+// that is not one of the key columns of the schema
+func crimeTypeColumns(header []string, cols *columns) map[int]string {
+	keyColumns := make(map[int]bool, len(cols.indexes))
+	for _, index := range cols.indexes {
+		keyColumns[index] = true
+	}
+	crimeTypes := make(map[int]string, len(header))
+	for i, crimeType := range header {
+		if !keyColumns[i] {
+			crimeTypes[i] = crimeType
+		}
+	}
+	return crimeTypes
+}
+
+func (s *CriminalityService) loadCommuneCrimes(ctx context.Context) error {
+	path := config.GetDataFilePath(s.csvConfig.CommuneCrimes)
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
@@ -49,38 +81,45 @@ func (s *CriminalityService) loadCommuneCrimes() error {
 	if err != nil {
 		return err
 	}
+	cols, err := loadColumns(DatasetCommuneCrimes, path, header, s.schemas[DatasetCommuneCrimes])
+	if err != nil {
+		return err
+	}
+	communeColumn := cols.index("commune_code")
+	crimeTypes := crimeTypeColumns(header, cols)
 
-	// Skip first column (CODGEO_2023)
-	crimeTypes := header[1:]
-
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err != nil {
 			break
 		}
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
 
 		if len(record) < len(header) {
+			s.communeCrimesSkipped++
 			continue
 		}
 
-		communeCode := strings.TrimLeft(record[0], "0")
+		communeCode := strings.TrimLeft(record[communeColumn], "0")
 		if _, exists := s.communeCrimes[communeCode]; !exists {
 			s.communeCrimes[communeCode] = make(map[string]float64)
 		}
 
 		// Process each crime type
 		for i, crimeType := range crimeTypes {
-			if i+1 >= len(record) {
-				continue
-			}
-			
 			// Initialize rate as 0
 			rate := 0.0
 			
 			// If the field is not empty, parse it as float
-			if record[i+1] != "" {
+			if record[i] != "" {
 				var err error
-				rate, err = strconv.ParseFloat(record[i+1], 64)
+				rate, err = strconv.ParseFloat(record[i], 64)
 				if err != nil {
 					continue
 				}
@@ -94,9 +133,9 @@ func (s *CriminalityService) loadCommuneCrimes() error {
 	return nil
 }
 
-func (s *CriminalityService) loadDepartmentCrimes() error {
-	csvConfig := config.GetCSVConfig()
-	file, err := os.Open(config.GetDataFilePath(csvConfig.DepartmentCrimes))
+func (s *CriminalityService) loadDepartmentCrimes(ctx context.Context) error {
+	path := config.GetDataFilePath(s.csvConfig.DepartmentCrimes)
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
@@ -110,42 +149,51 @@ func (s *CriminalityService) loadDepartmentCrimes() error {
 	if err != nil {
 		return err
 	}
+	cols, err := loadColumns(DatasetDepartmentCrimes, path, header, s.schemas[DatasetDepartmentCrimes])
+	if err != nil {
+		return err
+	}
+	departmentColumn := cols.index("department_code")
+	populationColumn := cols.index("population")
+	crimeTypes := crimeTypeColumns(header, cols)
 
-	// Skip first two columns (Code.département and POP)
-	crimeTypes := header[2:]
-
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err != nil {
 			break
 		}
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
 
 		if len(record) < len(header) {
+			s.departmentCrimesSkipped++
 			continue
 		}
 
-		departmentCode := strings.TrimLeft(record[0], "0")
+		departmentCode := strings.TrimLeft(record[departmentColumn], "0")
 
 		// Initialize department crimes map if not exists
 		if _, exists := s.departmentCrimes[departmentCode]; !exists {
 			s.departmentCrimes[departmentCode] = make(map[string]float64)
 		}
 
-		population, err := strconv.ParseFloat(record[1], 64)
+		population, err := strconv.ParseFloat(record[populationColumn], 64)
 		if err != nil {
+			s.departmentCrimesSkipped++
 			continue
 		}
 		s.departmentCrimes[departmentCode]["population"] = population
 
 		// Process each crime type
 		for i, crimeType := range crimeTypes {
-			if i+2 >= len(record) {
-				continue
-			}
-			
 			// Only store non-empty values
-			if record[i+2] != "" {
-				rate, err := strconv.ParseFloat(record[i+2], 64)
+			if record[i] != "" {
+				rate, err := strconv.ParseFloat(record[i], 64)
 				if err != nil {
 					continue
 				}
@@ -1,9 +1,10 @@
 package services
 
 import (
-	"bufio"
+	"context"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,11 +13,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/exp/slices"
 
 	"csv-processor/internal/config"
+	"csv-processor/internal/metrics"
 	"csv-processor/internal/models"
 
 	"github.com/twpayne/go-geom"
@@ -26,351 +29,241 @@ import (
 
 // CSVService handles the business logic for processing the CSV file
 type CSVService struct {
-	businessFilePath string
+	businessStore   *BusinessStore
 	irisFilePath    string
 	qpFilePath      string
 	communeFilePath string
 	incomeFilePath string
+	geoLayers       *GeoLayers
+	// Row counts of the loaded CSV files, by dataset name
+	loadStatsMu     sync.Mutex
+	loadStats       map[string]loadStats
+	workerPool      *WorkerPool // shared by all requests and generations for zone intersections
 	criminalityService *CriminalityService
 	competitionService *CompetitionService
+	csvConfig       config.CSVConfig // file names this generation was loaded from
+	schemas         config.SchemaConfig // columns read from these files
+	generation      int
 }
 
-// NewCSVService creates a new CSVService instance
-func NewCSVService() *CSVService {
+// NewCSVService creates a new CSVService instance and loads the business and zone CSV files
+// of the current configuration in memory. Loading stops early when ctx is done.
+// It fails before loading anything when a file misses a required column.
+func NewCSVService(ctx context.Context, workerPool *WorkerPool) (*CSVService, error) {
 	csvConfig := config.GetCSVConfig()
+	schemas := config.GetSchemaConfig()
+
+	if err := checkSchemas(csvConfig, schemas); err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	businessStore, err := NewBusinessStore(ctx, config.GetDataFilePath(csvConfig.BusinessData), schemas[DatasetBusinesses])
+	if err != nil {
+		return nil, fmt.Errorf("error loading businesses: %v", err)
+	}
+	businessLoadDuration := time.Since(startTime)
 	
-	criminalityService, err := NewCriminalityService()
+	criminalityService, err := NewCriminalityService(ctx, csvConfig, schemas)
 	if err != nil {
 		log.Printf("Warning: failed to initialize criminality service: %v", err)
 	}
 	
-	competitionService, err := NewCompetitionService()
+	competitionService, err := NewCompetitionService(config.GetDataFilePath(csvConfig.CompetitionData), schemas[DatasetCompetition])
 	if err != nil {
 		log.Printf("Warning: failed to initialize competition service: %v", err)
 	}
 
-	return &CSVService{
-		businessFilePath: config.GetDataFilePath(csvConfig.BusinessData),
+	service := &CSVService{
+		businessStore:   businessStore,
 		irisFilePath:    config.GetDataFilePath(csvConfig.IrisData),
 		qpFilePath:      config.GetDataFilePath(csvConfig.QPData),
 		communeFilePath: config.GetDataFilePath(csvConfig.CommuneData),
 		criminalityService: criminalityService,
 		competitionService: competitionService,
+		workerPool:      workerPool,
+		csvConfig:       csvConfig,
+		schemas:         schemas,
 	}
-}
 
-// convertGeoJSONToPolygon converts GeoJSON polygon coordinates to a go-geom Polygon
-func (s *CSVService) convertGeoJSONToPolygon(geojsonStr string) (*geom.Polygon, error) {
-	// First try parsing as a regular Polygon
-	var polygonGeoJSON struct {
-		Type        string          `json:"type"`
-		Coordinates [][][]float64   `json:"coordinates"`
+	service.recordLoad(DatasetBusinesses, loadStats{
+		rows:     businessStore.Len(),
+		skipped:  businessStore.SkippedRows(),
+		bytes:    businessStore.SizeBytes(),
+		duration: businessLoadDuration,
+	})
+	if criminalityService != nil {
+		service.recordLoad(DatasetCommuneCrimes, criminalityService.communeCrimesStats)
+		service.recordLoad(DatasetDepartmentCrimes, criminalityService.departmentCrimesStats)
 	}
 
-	if err := json.Unmarshal([]byte(geojsonStr), &polygonGeoJSON); err == nil && polygonGeoJSON.Type == "Polygon" {
-		if len(polygonGeoJSON.Coordinates) == 0 {
-			return nil, fmt.Errorf("empty polygon coordinates")
-		}
-
-		coords := polygonGeoJSON.Coordinates[0]
-		polygon := geom.NewPolygon(geom.XY)
-		coords2D := make([][]geom.Coord, 1)
-		coords2D[0] = make([]geom.Coord, len(coords))
-		for i, coord := range coords {
-			coords2D[0][i] = geom.Coord{coord[0], coord[1]}
-		}
-		_, err := polygon.SetCoords(coords2D)
-		if err != nil {
-			return nil, fmt.Errorf("error creating polygon: %v", err)
-		}
-		return polygon, nil
+	geoLayers, err := service.loadGeoLayers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading geometry layers: %v", err)
 	}
+	service.geoLayers = geoLayers
 
-	// If not a Polygon, try parsing as a MultiPolygon
-	var multiPolygonGeoJSON struct {
-		Type        string            `json:"type"`
-		Coordinates [][][][]float64   `json:"coordinates"`
-	}
+	return service, nil
+}
 
-	if err := json.Unmarshal([]byte(geojsonStr), &multiPolygonGeoJSON); err != nil {
+// parseGeoJSONGeometry parses a GeoJSON Polygon or MultiPolygon and checks its coordinates
+func parseGeoJSONGeometry(geojsonStr string) (*models.GeoJSONGeometry, error) {
+	var geometry models.GeoJSONGeometry
+	if err := json.Unmarshal([]byte(geojsonStr), &geometry); err != nil {
 		return nil, fmt.Errorf("error parsing GeoJSON: %v", err)
 	}
 
-	if multiPolygonGeoJSON.Type != "MultiPolygon" {
-		return nil, fmt.Errorf("unsupported GeoJSON type: %s", multiPolygonGeoJSON.Type)
+	if !geometry.IsPolygonal() {
+		return nil, fmt.Errorf("unsupported GeoJSON type: %s", geometry.Type)
 	}
 
-	if len(multiPolygonGeoJSON.Coordinates) == 0 || len(multiPolygonGeoJSON.Coordinates[0]) == 0 {
-		return nil, fmt.Errorf("empty MultiPolygon coordinates")
+	if len(geometry.Coordinates) == 0 {
+		return nil, fmt.Errorf("empty %s coordinates", geometry.Type)
 	}
 
-	// Use the first polygon from the MultiPolygon
-	coords := multiPolygonGeoJSON.Coordinates[0][0]
-	polygon := geom.NewPolygon(geom.XY)
-	coords2D := make([][]geom.Coord, 1)
-	coords2D[0] = make([]geom.Coord, len(coords))
-	for i, coord := range coords {
-		coords2D[0][i] = geom.Coord{coord[0], coord[1]}
-	}
-	_, err := polygon.SetCoords(coords2D)
-	if err != nil {
-		return nil, fmt.Errorf("error creating polygon from MultiPolygon: %v", err)
-	}
-	return polygon, nil
-}
-
-func (s *CSVService) convertGeoJSONToGeometry(geojsonStr string) (geom2.Geometry, error) {
-	// First try parsing as a regular Polygon
-	var polygonGeoJSON struct {
-		Type        string          `json:"type"`
-		Coordinates [][][]float64   `json:"coordinates"`
-	}
-
-	if err := json.Unmarshal([]byte(geojsonStr), &polygonGeoJSON); err == nil && polygonGeoJSON.Type == "Polygon" {
-		if len(polygonGeoJSON.Coordinates) == 0 {
-			return geom2.Geometry{}, fmt.Errorf("empty polygon coordinates")
-		}
-
-		// Convert coordinates to geom2.Geometry format
-		coords := polygonGeoJSON.Coordinates[0]
-		flatCoords := make([]float64, len(coords)*2)
-		for i, coord := range coords {
-			flatCoords[i*2] = coord[0]
-			flatCoords[i*2+1] = coord[1]
-		}
-		
-		// Create line string from points
-		lineString := geom2.NewLineString(geom2.NewSequence(flatCoords, geom2.DimXY))
-		if lineString.IsEmpty() {
-			return geom2.Geometry{}, fmt.Errorf("error creating line string")
+	for i, rings := range geometry.Coordinates {
+		if len(rings) == 0 {
+			return nil, fmt.Errorf("empty coordinates for polygon %d", i)
 		}
-
-		// Create polygon from line string
-		polygon := geom2.NewPolygon([]geom2.LineString{lineString})
-		if polygon.IsEmpty() {
-			return geom2.Geometry{}, fmt.Errorf("error creating polygon")
+		for j, ring := range rings {
+			for k, coord := range ring {
+				if len(coord) < 2 {
+					return nil, fmt.Errorf("invalid position %d in ring %d of polygon %d", k, j, i)
+				}
+			}
 		}
-		return polygon.AsGeometry(), nil
-	}
-
-	// If not a Polygon, try parsing as a MultiPolygon
-	var multiPolygonGeoJSON struct {
-		Type        string            `json:"type"`
-		Coordinates [][][][]float64   `json:"coordinates"`
-	}
-
-	if err := json.Unmarshal([]byte(geojsonStr), &multiPolygonGeoJSON); err != nil {
-		return geom2.Geometry{}, fmt.Errorf("error parsing GeoJSON: %v", err)
-	}
-
-	if multiPolygonGeoJSON.Type != "MultiPolygon" {
-		return geom2.Geometry{}, fmt.Errorf("unsupported GeoJSON type: %s", multiPolygonGeoJSON.Type)
-	}
-
-	if len(multiPolygonGeoJSON.Coordinates) == 0 || len(multiPolygonGeoJSON.Coordinates[0]) == 0 {
-		return geom2.Geometry{}, fmt.Errorf("empty MultiPolygon coordinates")
 	}
 
-	// Use the first polygon from the MultiPolygon
-	coords := multiPolygonGeoJSON.Coordinates[0][0]
-	flatCoords := make([]float64, len(coords)*2)
-	for i, coord := range coords {
-		flatCoords[i*2] = coord[0]
-		flatCoords[i*2+1] = coord[1]
-	}
-
-	// Create line string from points
-	lineString := geom2.NewLineString(geom2.NewSequence(flatCoords, geom2.DimXY))
-	if lineString.IsEmpty() {
-		return geom2.Geometry{}, fmt.Errorf("error creating line string")
-	}
-
-	// Create polygon from line string
-	polygon := geom2.NewPolygon([]geom2.LineString{lineString})
-	if polygon.IsEmpty() {
-		return geom2.Geometry{}, fmt.Errorf("error creating polygon from MultiPolygon")
-	}
-	return polygon.AsGeometry(), nil
+	return &geometry, nil
 }
 
-// writeResultsToFile writes the search results to a JSON file
-func (s *CSVService) writeResultsToFile(businesses []*models.Business, nafCode string) error {
-	// Create results directory if it doesn't exist
-	resultsDir := "results"
-	if err := os.MkdirAll(resultsDir, 0755); err != nil {
-		return fmt.Errorf("error creating results directory: %v", err)
-	}
-
-	// Generate filename with timestamp and NAF code
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s/results_%s_%s.json", resultsDir, nafCode, timestamp)
-
-	// Create and write to the file
-	file, err := os.Create(filename)
+// convertGeoJSONToPolygon converts a GeoJSON Polygon or MultiPolygon to a go-geom
+// Polygon or MultiPolygon, keeping every ring of every polygon
+func (s *CSVService) convertGeoJSONToPolygon(geojsonStr string) (geom.T, error) {
+	geometry, err := parseGeoJSONGeometry(geojsonStr)
 	if err != nil {
-		return fmt.Errorf("error creating results file: %v", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	// Write JSON with indentation
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(businesses); err != nil {
-		return fmt.Errorf("error writing results to file: %v", err)
+	polygons := make([][][]geom.Coord, len(geometry.Coordinates))
+	for i, rings := range geometry.Coordinates {
+		polygons[i] = make([][]geom.Coord, len(rings))
+		for j, ring := range rings {
+			polygons[i][j] = make([]geom.Coord, len(ring))
+			for k, coord := range ring {
+				polygons[i][j][k] = geom.Coord{coord[0], coord[1]}
+			}
+		}
 	}
 
-	return nil
-}
-
-// SearchBusinesses searches for businesses matching the given criteria
-func (s *CSVService) SearchBusinesses(geojsonStr string, nafCodes []string, write bool) ([]*models.Business, error) {
-	// Convert GeoJSON to geometry
-	geometry, err := s.convertGeoJSONToGeometry(geojsonStr)
-	if err != nil {
-		return nil, fmt.Errorf("error converting GeoJSON to geometry: %v", err)
+	if geometry.Type == "Polygon" {
+		polygon, err := geom.NewPolygon(geom.XY).SetCoords(polygons[0])
+		if err != nil {
+			return nil, fmt.Errorf("error creating polygon: %v", err)
+		}
+		return polygon, nil
 	}
 
-	// Load only businesses with matching NAF codes
-	businesses, err := s.loadBusinessesByNAF(nafCodes)
+	multiPolygon, err := geom.NewMultiPolygon(geom.XY).SetCoords(polygons)
 	if err != nil {
-		return nil, fmt.Errorf("error loading businesses: %v", err)
+		return nil, fmt.Errorf("error creating MultiPolygon: %v", err)
 	}
-
-	// Create spatial index with filtered businesses
-	spatialIndex := models.NewSpatialIndex(businesses)
-
-	// Query businesses within geometry
-	results := spatialIndex.Query(geometry)
-
-	// Write results to file
-	if write {
-		if err := s.writeResultsToFile(results, strings.Join(nafCodes, "_")); err != nil {
-			log.Printf("Warning: error writing results to file: %v", err)
-		}
-	}
-
-	return results, nil
+	return multiPolygon, nil
 }
 
-// loadBusinessesByNAF loads only businesses with any of the given NAF codes
-func (s *CSVService) loadBusinessesByNAF(nafCodes []string) ([]*models.Business, error) {
-	file, err := os.Open(s.businessFilePath)
+// convertGeoJSONToGeometry converts a GeoJSON Polygon or MultiPolygon to a geometry,
+// keeping the holes of every polygon and every part of a MultiPolygon
+func (s *CSVService) convertGeoJSONToGeometry(geojsonStr string) (geom2.Geometry, error) {
+	geometry, err := parseGeoJSONGeometry(geojsonStr)
 	if err != nil {
-		return nil, fmt.Errorf("error opening CSV file: %v", err)
+		return geom2.Geometry{}, err
 	}
-	defer file.Close()
 
-	// Use buffered reader for better performance
-	bufReader := bufio.NewReader(file)
-	reader := csv.NewReader(bufReader)
-	reader.LazyQuotes = true
-	reader.FieldsPerRecord = -1
-	reader.ReuseRecord = true // Reuse record slice for better memory usage
+	polygons := make([]geom2.Polygon, 0, len(geometry.Coordinates))
+	for i, rings := range geometry.Coordinates {
+		lineStrings := make([]geom2.LineString, 0, len(rings))
+		for j, ring := range rings {
+			flatCoords := make([]float64, len(ring)*2)
+			for k, coord := range ring {
+				flatCoords[k*2] = coord[0]
+				flatCoords[k*2+1] = coord[1]
+			}
 
-	// Pre-allocate slice with reasonable capacity
-	businesses := make([]*models.Business, 0, 1000)
+			// Create line string from points
+			lineString := geom2.NewLineString(geom2.NewSequence(flatCoords, geom2.DimXY))
+			if lineString.IsEmpty() {
+				return geom2.Geometry{}, fmt.Errorf("error creating ring %d of polygon %d", j, i)
+			}
+			lineStrings = append(lineStrings, lineString)
+		}
 
-	// Skip header
-	_, err = reader.Read()
-	if err != nil {
-		return nil, fmt.Errorf("error reading CSV header: %v", err)
+		// Create polygon from its exterior ring and holes
+		polygon := geom2.NewPolygon(lineStrings)
+		if polygon.IsEmpty() {
+			return geom2.Geometry{}, fmt.Errorf("error creating polygon %d", i)
+		}
+		polygons = append(polygons, polygon)
 	}
 
-	// Pre-allocate address builder with reasonable capacity
-	var address strings.Builder
-	address.Grow(200)
+	if geometry.Type == "Polygon" {
+		return polygons[0].AsGeometry(), nil
+	}
+	return geom2.NewMultiPolygon(polygons).AsGeometry(), nil
+}
 
-	// Create a map for faster NAF code lookups
-	nafCodeMap := make(map[string]bool)
-	for _, code := range nafCodes {
-		nafCodeMap[code] = true
+// UnionGeoJSON returns the union of several GeoJSON Polygon or MultiPolygon geometries
+func (s *CSVService) UnionGeoJSON(geometries []models.GeoJSONGeometry) (*models.GeoJSONGeometry, error) {
+	if len(geometries) == 0 {
+		return nil, newServiceError(ErrorInvalidGeometry, "No geometry to merge", nil)
 	}
 
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
+	var union geom2.Geometry
+	for i, geometry := range geometries {
+		geojsonStr, err := json.Marshal(geometry)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("error encoding geometry %d: %v", i, err)
 		}
-
-		if len(record) < 20 {
-			continue
-		}
-
-		// Parse NAF code first to filter early
-		recordNAFCode := record[len(record)-5]
-		if !nafCodeMap[recordNAFCode] {
-			continue
-		}
-
-		// Parse business name
-		businessName := record[len(record)-6]
-		if businessName == "" {
-			continue
-		}
-
-		if len(businessName) < 9 {
-			businessName = "0" + businessName
-		}
-
-		// Parse siret
-		siret := record[2]
-		if siret == "" {
-			continue
-		}
-		
-		if len(siret) < 14 {
-			siret = "0" + siret
+		polygon, err := s.convertGeoJSONToGeometry(string(geojsonStr))
+		if err != nil {
+			return nil, newServiceError(ErrorInvalidGeometry, "Invalid GeoJSON geometry", err).WithDetail("feature", i)
 		}
 
-		// Parse coordinates
-		longitude, err := strconv.ParseFloat(record[len(record)-2], 64)
-		if err != nil {
+		if i == 0 {
+			union = polygon
 			continue
 		}
-		latitude, err := strconv.ParseFloat(record[len(record)-1], 64)
+		union, err = geom2.Union(union, polygon)
 		if err != nil {
-			continue
+			return nil, newServiceError(ErrorInvalidGeometry, "Geometries cannot be merged", err).WithDetail("feature", i)
 		}
+	}
 
-		// Reset address builder
-		address.Reset()
-
-		// Parse address more efficiently
-		addressParts := []string{
-			record[11],  // complementAdresseEtablissement
-			record[12],  // numeroVoieEtablissement
-			record[16],  // typeVoieEtablissement
-			record[17],  // libelleVoieEtablissement
-			record[18],  // codePostalEtablissement
-			record[19],  // libelleCommuneEtablissement
-		}
+	geojsonStr, err := union.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding union: %v", err)
+	}
+	return parseGeoJSONGeometry(string(geojsonStr))
+}
 
-		for i, part := range addressParts {
-			if part != "" {
-				if i > 0 && addressParts[i-1] != "" {
-					address.WriteString(" ")
-				}
-				address.WriteString(part)
-			}
-		}
+// SearchBusinesses searches for businesses matching the given criteria
+func (s *CSVService) SearchBusinesses(ctx context.Context, geojsonStr string, nafCodes []string) ([]*models.Business, error) {
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, "search")
+	}
 
-		// Create business entry
-		business := &models.Business{
-			Name:      businessName,
-			Siret: siret,
-			NAFCode:   recordNAFCode,
-			Latitude:  latitude,
-			Longitude: longitude,
-			Address:   address.String(),
-		}
+	// Convert GeoJSON to geometry
+	geometry, err := s.convertGeoJSONToGeometry(geojsonStr)
+	if err != nil {
+		return nil, newServiceError(ErrorInvalidGeometry, "Invalid GeoJSON geometry", err)
+	}
 
-		businesses = append(businesses, business)
+	// Query businesses with matching NAF codes within geometry
+	results := s.businessStore.SearchByNAF(geometry, nafCodes)
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, "search").WithDetail("businesses_found", len(results))
 	}
-	
-	return businesses, nil
+
+	return results, nil
 }
 
 // parsePolygon parses a polygon from a GeoJSON string
@@ -386,36 +279,8 @@ func (s *CSVService) parsePolygon(polygonStr string) *geom2.Geometry {
 	return &polygon
 }
 
-// writeIrisResultsToFile writes the IRIS data results to a JSON file
-func (s *CSVService) writeIrisResultsToFile(response *models.IrisResponse) error {
-	// Create results directory if it doesn't exist
-	resultsDir := "results"
-	if err := os.MkdirAll(resultsDir, 0755); err != nil {
-		return fmt.Errorf("error creating results directory: %v", err)
-	}
-
-	// Generate filename with timestamp
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s/iris_results_%s.json", resultsDir, timestamp)
-
-	// Create and write to the file
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("error creating results file: %v", err)
-	}
-	defer file.Close()
-
-	// Write JSON with indentation
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(response); err != nil {
-		return fmt.Errorf("error writing results to file: %v", err)
-	}
-
-	log.Printf("Results written to: %s", filename)
-	return nil
-}
-
+// calculateIntersectionArea returns the area shared by two polygons, in the unit of their coordinates.
+// Polygons are expected in Lambert-93 so the result is in square meters.
 func calculateIntersectionArea(requestPoly, irisPoly geom2.Geometry) float64 {
 	// First check if they intersect at all - this is the fastest check
 	if !geom2.Intersects(requestPoly, irisPoly) {
@@ -440,8 +305,12 @@ func calculateIntersectionArea(requestPoly, irisPoly geom2.Geometry) float64 {
 	return intersection.Area()
 }
 
-// calculateIntersectionPercentage calculates the percentage of intersection between two polygons
+// calculateIntersectionPercentage calculates the percentage of intersection between two Lambert-93 polygons
 func calculateIntersectionPercentage(requestPoly, irisPoly *geom2.Geometry) float64 {
+	if requestPoly == nil || irisPoly == nil {
+		return 0
+	}
+
 	// Calculate intersection area
 	intersectionArea := calculateIntersectionArea(*requestPoly, *irisPoly)
 	if intersectionArea == 0 {
@@ -480,13 +349,8 @@ func aggregateIrisData(response *models.IrisResponse, iris *models.IrisData, inc
 }
 
 // loadQPData loads QP data from the CSV file
-func (s *CSVService) loadQPData() ([]struct {
-	ID string
-	CodeQP string
-	LibQP    string
-	Commune  string
-	Polygon  *geom2.Geometry
-}, error) {
+func (s *CSVService) loadQPData(ctx context.Context) ([]*qpZone, error) {
+	startTime := time.Now()
 	file, err := os.Open(s.qpFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening QP CSV file: %v", err)
@@ -497,39 +361,39 @@ func (s *CSVService) loadQPData() ([]struct {
 	reader.Comma = ';' // Set semicolon as delimiter
 	reader.LazyQuotes = true
 
-	// Skip header
-	_, err = reader.Read()
+	// Locate the columns in the header
+	header, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("error reading CSV header: %v", err)
 	}
-
-	var qpData []struct {
-		ID string
-		CodeQP string
-		LibQP    string
-		Commune  string
-		Polygon  *geom2.Geometry
+	cols, err := loadColumns(DatasetQP, s.qpFilePath, header, s.schemas[DatasetQP])
+	if err != nil {
+		return nil, err
 	}
 
+	var qpData []*qpZone
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			continue
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("loading QP data interrupted after %d rows: %w", rowsRead, err)
+			}
 		}
-
-		if len(record) < 8 { // We need at least 8 columns
+		if err != nil {
 			continue
 		}
 
 		// Parse QP data
-		id := record[0]
-		codeQP := record[1]
-		libQP := record[2]    // Lib_QP
-		commune := record[3]  // Commune
-		polygonStr := record[6] // polygon
+		id := cols.get(record, "id")
+		codeQP := cols.get(record, "code_qp")
+		libQP := cols.get(record, "label")
+		commune := cols.get(record, "commune")
+		polygonStr := cols.get(record, "polygon")
 
 		if polygonStr == "" {
 			continue
@@ -540,26 +404,24 @@ func (s *CSVService) loadQPData() ([]struct {
 			continue
 		}
 
-		qpData = append(qpData, struct {
-			ID string
-			CodeQP string
-			LibQP    string
-			Commune  string
-			Polygon  *geom2.Geometry
-		}{
-			ID: id,
-			CodeQP: codeQP,
-			LibQP:    libQP,
-			Commune:  commune,
+		// Clone kept fields so they do not retain the whole CSV line in memory
+		qpData = append(qpData, &qpZone{
+			ID: strings.Clone(id),
+			CodeQP: strings.Clone(codeQP),
+			LibQP:    strings.Clone(libQP),
+			Commune:  strings.Clone(commune),
 			Polygon:  polygon,
+			ProjectedPolygon: projectPolygon(polygon),
 		})
 	}
 
+	s.recordLoad(DatasetQP, loadStats{rows: len(qpData), skipped: rowsRead - len(qpData), duration: time.Since(startTime)})
 	return qpData, nil
 }
 
-// loadCommuneData loads commune data from the CSV file for specific commune codes
-func (s *CSVService) loadCommuneData(communeCodes map[string]bool) (map[string]*models.CommuneData, error) {
+// loadCommuneData loads all communes from the CSV file
+func (s *CSVService) loadCommuneData(ctx context.Context) ([]*models.CommuneData, error) {
+	startTime := time.Now()
 	file, err := os.Open(s.communeFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening commune CSV file: %v", err)
@@ -570,68 +432,75 @@ func (s *CSVService) loadCommuneData(communeCodes map[string]bool) (map[string]*
 	reader.Comma = ';' // Set semicolon as delimiter
 	reader.LazyQuotes = true
 
-	// Skip header
-	_, err = reader.Read()
+	// Locate the columns in the header
+	header, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("error reading CSV header: %v", err)
 	}
+	cols, err := loadColumns(DatasetCommunes, s.communeFilePath, header, s.schemas[DatasetCommunes])
+	if err != nil {
+		return nil, err
+	}
 
-	communeData := make(map[string]*models.CommuneData)
+	var communeData []*models.CommuneData
 	lineNumber := 0
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			continue
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("loading commune data interrupted after %d rows: %w", rowsRead, err)
+			}
 		}
-
-		if len(record) < 7 { // Make sure we have enough columns
+		if err != nil {
 			continue
 		}
 
-		communeCode := record[0]
-		// Skip if this commune is not in our target set
-		if !communeCodes[communeCode] {
-			continue
-		}
+		polygon := s.parsePolygon(cols.get(record, "polygon"))
 
 		// Create CommuneData struct with values from the record
-		communeData[communeCode] = &models.CommuneData{
+		communeData = append(communeData, &models.CommuneData{
 			// id is the line number
 			ID: strconv.Itoa(lineNumber),
-			CommuneCode: communeCode,
-			Population: parseFloat(record[1]),
-			CommuneName: record[len(record)-6],
-			PostalCode:  record[len(record)-7],
-			SurfaceArea: parseFloat(record[len(record)-5]),
-			Polygon: s.parsePolygon(record[len(record)-11]),
-			AverageIncome: parseFloat(record[len(record)-1]),
-		}
+			CommuneCode: strings.Clone(cols.get(record, "commune_code")),
+			Population: parseFloat(cols.get(record, "population")),
+			CommuneName: strings.Clone(cols.get(record, "name")),
+			PostalCode:  strings.Clone(cols.get(record, "postal_code")),
+			SurfaceArea: parseFloat(cols.get(record, "surface_area")),
+			Polygon: polygon,
+			ProjectedPolygon: projectPolygon(polygon),
+			AverageIncome: parseFloat(cols.get(record, "median_income")),
+		})
 		lineNumber++
 	}
 
+	s.recordLoad(DatasetCommunes, loadStats{rows: len(communeData), skipped: rowsRead - len(communeData), duration: time.Since(startTime)})
 	return communeData, nil
 }
 
-// GetIrisData retrieves and aggregates IRIS data for the given polygon
-func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error) {
+// GetIrisData retrieves and aggregates IRIS data for the given polygon.
+// It stops when ctx is done and reports how many zones were processed.
+func (s *CSVService) GetIrisData(ctx context.Context, geojsonStr string, options IrisOptions) (*models.IrisResponse, error) {
 	// Convert GeoJSON to polygon
 	polygon, err := s.convertGeoJSONToGeometry(geojsonStr)
 	if err != nil {
-		return nil, fmt.Errorf("error converting GeoJSON to polygon: %v", err)
+		return nil, newServiceError(ErrorInvalidGeometry, "Invalid GeoJSON geometry", err)
 	}
 
 	if polygon.IsEmpty() {
-		return nil, fmt.Errorf("failed to create polygon from GeoJSON")
+		return nil, newServiceError(ErrorInvalidGeometry, "GeoJSON geometry is empty", nil)
 	}
 
-	// Load IRIS data
-	irisData, err := s.loadIrisData()
-	if err != nil {
-		return nil, fmt.Errorf("error loading IRIS data: %v", err)
-	}
+	// Areas are computed in Lambert-93 so they are in square meters
+	projectedPolygon := models.ProjectToLambert93(polygon)
+
+	// Only IRIS zones whose envelope overlaps the request polygon can intersect it
+	envelope := models.GeometryEnvelope(polygon)
+	irisData := s.geoLayers.irisCandidates(envelope)
 
 	// Initialize response with IRIS data
 	response := &models.IrisResponse{
@@ -650,70 +519,42 @@ func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error
 		},
 	}
 
-	// Create channels for results and errors
-	type result struct {
-		iris *models.IrisData
-		percentage float64
-	}
-	results := make(chan result, len(irisData))
-	errors := make(chan error, len(irisData))
-
-	// Create a WaitGroup to wait for all goroutines to finish
-	var wg sync.WaitGroup
-
-	// Process IRIS zones in parallel
-	for _, iris := range irisData {
-		wg.Add(1)
-		go func(iris *models.IrisData) {
-			defer wg.Done()
-			if iris.Polygon == nil {
-				return
-			}
-
-			// Calculate intersection percentage
-			inclusionPercentage := calculateIntersectionPercentage(&polygon, iris.Polygon)
-			// inclusionPercentage is always > 0 and < 100
-			results <- result{iris: iris, percentage: inclusionPercentage}
-		}(iris)
+	// Compute the intersections on the shared worker pool
+	percentages, processedZones, err := s.irisIntersections(ctx, projectedPolygon, irisData)
+	if err != nil {
+		return nil, newServiceError(ErrorDataUnavailable, "Server is shutting down", err)
 	}
-
-	// Close results channel when all goroutines are done
-	go func() {
-		wg.Wait()
-		close(results)
-		close(errors)
-	}()
+	metrics.ZonesScanned.Add(float64(processedZones), "iris")
 
 	// Track intersecting communes to load only relevant ones
 	intersectingCommunes := make(map[string]bool)
 	intersectingZones := 0
 
 	// Process results
-	for result := range results {
-		if result.percentage > 0 {  // Only count zones that actually intersect
+	for i, iris := range irisData {
+		if percentages[i] > 0 {  // Only count zones that actually intersect
 			intersectingZones++
 			// Aggregate data with inclusion percentage
-			aggregateIrisData(response, result.iris, result.percentage)
+			aggregateIrisData(response, iris, percentages[i])
+			if options.Breakdown {
+				response.Breakdown = append(response.Breakdown, irisBreakdown(iris, percentages[i], options))
+			}
 			// Track this commune for later processing
-			intersectingCommunes[result.iris.COM] = true
+			intersectingCommunes[iris.COM] = true
 		}
 	}
 
-	// Check for errors
-	for err := range errors {
-		if err != nil {
-			return nil, fmt.Errorf("error processing IRIS data: %v", err)
-		}
-	}
+	metrics.ZonesMatched.Add(float64(intersectingZones), "iris")
 
-	if intersectingZones == 0 {
-		return nil, fmt.Errorf("no intersecting zones found")
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, "iris").
+			WithDetail("processed_zones", processedZones).
+			WithDetail("total_zones", len(irisData)).
+			WithDetail("intersecting_zones", intersectingZones)
 	}
 
-	// Load only relevant commune data
-	communeData, err := s.loadCommuneData(intersectingCommunes)
-	if err != nil {
-		return nil, fmt.Errorf("error loading commune data: %v", err)
+	if intersectingZones == 0 {
+		return nil, newServiceError(ErrorNotFound, "No intersecting zones found", nil)
 	}
 
 	totalIncome := 0.0
@@ -730,16 +571,25 @@ func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error
 	}
 	postalCodeStatsMap := make(map[string]*postalCodeStats)
 
+	processedCommunes := 0
 	for communeCode := range intersectingCommunes {
-		if communeValue, exists := communeData[communeCode]; exists {
-			communeInclusionPercentage := calculateIntersectionPercentage(&polygon, communeValue.Polygon)
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, "communes").
+				WithDetail("processed_communes", processedCommunes).
+				WithDetail("total_communes", len(intersectingCommunes))
+		}
+		processedCommunes++
+		if preloadedCommune, exists := s.geoLayers.communesByCode[communeCode]; exists {
+			// Work on a copy, the preloaded commune is shared by concurrent requests
+			communeValue := *preloadedCommune
+			communeInclusionPercentage := calculateIntersectionPercentage(&projectedPolygon, communeValue.ProjectedPolygon)
 			if communeInclusionPercentage == 0 {
 				continue
 			}
 			// append only if it's not already in the array
-			if !slices.Contains(response.Administrative.Communes, *communeValue) {
+			if !slices.Contains(response.Administrative.Communes, communeValue) {
 				communeValue.Percentage = math.Round(communeInclusionPercentage)
-				response.Administrative.Communes = append(response.Administrative.Communes, *communeValue)
+				response.Administrative.Communes = append(response.Administrative.Communes, communeValue)
 				// Split postal codes by comma and calculate weighted average for each
 				for postalCode := range strings.SplitSeq(communeValue.PostalCode, ",") {
 					// Trim whitespace from postal code
@@ -769,6 +619,9 @@ func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error
 		}
 	}
 
+	metrics.ZonesScanned.Add(float64(processedCommunes), "communes")
+	metrics.ZonesMatched.Add(float64(len(response.Administrative.Communes)), "communes")
+
 	// Convert postal code data to array with weighted average percentages
 	for postalCode, stats := range postalCodeStatsMap {
 		// Calculate weighted average percentage
@@ -802,20 +655,22 @@ func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error
 	response.Data.MedianIncome.IsFullyCovered = allAreasHaveIncomeData
 	response.Data.MedianIncome.PercentageAreaCovered = percentageAreaCovered
 
-	// Load and process QP data
-	qpData, err := s.loadQPData()
-	if err != nil {
-		return nil, fmt.Errorf("error loading QP data: %v", err)
-	}
+	// Only QP zones whose envelope overlaps the request polygon can intersect it
+	qpData := s.geoLayers.qpCandidates(envelope)
 
 	// Process QP data
-	for _, qp := range qpData {
+	for i, qp := range qpData {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, "special_zones").
+				WithDetail("processed_zones", i).
+				WithDetail("total_zones", len(qpData))
+		}
 		if qp.Polygon == nil {
 			continue
 		}
 
 		// Calculate intersection percentage
-		inclusionPercentage := calculateIntersectionPercentage(&polygon, qp.Polygon)
+		inclusionPercentage := calculateIntersectionPercentage(&projectedPolygon, qp.ProjectedPolygon)
 		if inclusionPercentage == 0 {
 			continue
 		}
@@ -830,23 +685,52 @@ func (s *CSVService) GetIrisData(geojsonStr string) (*models.IrisResponse, error
 		}
 	}
 
+	metrics.ZonesScanned.Add(float64(len(qpData)), "qp")
+	metrics.ZonesMatched.Add(float64(len(response.Administrative.SpecialZones)), "qp")
+
 	// Calculate criminality data if service is available
 	if s.criminalityService != nil {
 		response.Criminality = *s.criminalityService.CalculateCriminality(response.Administrative.Communes)
 	}
 
+	// Add the request polygon's area and population density
+	response.AreaKm2 = projectedPolygon.Area() / 1e6
+	if response.AreaKm2 > 0 {
+		response.PopulationDensity = response.TotalPopulation / response.AreaKm2
+	}
+
 	log.Printf("Found %d intersecting zones", intersectingZones)
+	response.Data.OtherData = selectStatistics(response.Data.OtherData, options)
+	sortBreakdown(response.Breakdown)
+
+	return response, nil
+}
+
+// irisIntersections returns the percentage of each IRIS zone covered by the projected request polygon,
+// computed on the shared worker pool, along with the number of zones processed before ctx was done.
+// It fails only when the pool is closed.
+func (s *CSVService) irisIntersections(ctx context.Context, projectedPolygon geom2.Geometry, irisData []*models.IrisData) ([]float64, int, error) {
+	percentages := make([]float64, len(irisData))
+	var processed atomic.Int64
 
-	// Write results to file
-	if err := s.writeIrisResultsToFile(response); err != nil {
-		log.Printf("Warning: error writing results to file: %v", err)
+	// A done context is reported by the caller, with the number of zones processed
+	err := s.workerPool.Run(ctx, len(irisData), func(i int) {
+		if irisData[i].Polygon == nil || ctx.Err() != nil {
+			return
+		}
+		percentages[i] = calculateIntersectionPercentage(&projectedPolygon, irisData[i].ProjectedPolygon)
+		processed.Add(1)
+	})
+	if errors.Is(err, ErrPoolClosed) {
+		return nil, 0, err
 	}
 
-	return response, nil
+	return percentages, int(processed.Load()), nil
 }
 
 // loadIrisData loads IRIS data from the CSV file
-func (s *CSVService) loadIrisData() ([]*models.IrisData, error) {
+func (s *CSVService) loadIrisData(ctx context.Context) ([]*models.IrisData, error) {
+	startTime := time.Now()
 	file, err := os.Open(s.irisFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("error opening IRIS CSV file: %v", err)
@@ -857,118 +741,81 @@ func (s *CSVService) loadIrisData() ([]*models.IrisData, error) {
 	reader.Comma = ';' // Set semicolon as delimiter
 	reader.LazyQuotes = true
 
-	// Skip header
-	_, err = reader.Read()
+	// Locate the columns in the header
+	header, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("error reading CSV header: %v", err)
 	}
+	cols, err := loadColumns(DatasetIris, s.irisFilePath, header, s.schemas[DatasetIris])
+	if err != nil {
+		return nil, err
+	}
+	statistics := irisStatisticColumns(cols)
 
 	var irisData []*models.IrisData
+	rowsRead := 0
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
+		rowsRead++
+		if rowsRead%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("loading IRIS data interrupted after %d rows: %w", rowsRead, err)
+			}
+		}
 		if err != nil {
 			continue
 		}
 
-		iris := s.parseIrisRecord(record)
+		iris := s.parseIrisRecord(record, cols, statistics)
 		if iris != nil {
 			irisData = append(irisData, iris)
 		}
 	}
 
+	s.recordLoad(DatasetIris, loadStats{rows: len(irisData), skipped: rowsRead - len(irisData), duration: time.Since(startTime)})
 	return irisData, nil
 }
 
-// parseIrisRecord parses a single IRIS record from the CSV
-func (s *CSVService) parseIrisRecord(record []string) *models.IrisData {
-	if len(record) < 119 { // We need at least 119 columns
-		return nil
+// irisIdentifierFields are the fields of the IRIS schema that are not statistics
+var irisIdentifierFields = []string{"iris", "commune", "type", "label", "polygon", "area"}
+
+// irisStatisticColumn is a statistic of the IRIS file and the position of its column
+type irisStatisticColumn struct {
+	key   string
+	index int
+}
+
+// irisStatisticColumns returns the statistic columns of the IRIS file: every field of its schema
+// other than the identifiers
+func irisStatisticColumns(cols *columns) []irisStatisticColumn {
+	statistics := make([]irisStatisticColumn, 0, len(cols.indexes))
+	for field, index := range cols.indexes {
+		if !slices.Contains(irisIdentifierFields, field) {
+			statistics = append(statistics, irisStatisticColumn{key: field, index: index})
+		}
 	}
+	return statistics
+}
 
+// parseIrisRecord parses a single IRIS record from the CSV
+func (s *CSVService) parseIrisRecord(record []string, cols *columns, statistics []irisStatisticColumn) *models.IrisData {
 	iris := &models.IrisData{
-		RawData: make(map[string]float64),
+		RawData: make(map[string]float64, len(statistics)),
 	}
 
 	// Store all raw values except IRIS, COM, TYP_IRIS, LAB_IRIS
-	iris.COM = record[1]
-	iris.RawData["population_total"] = parseFloat(record[4])
-	iris.RawData["population_general_age_0002"] = parseFloat(record[5])
-	iris.RawData["population_general_age_0305"] = parseFloat(record[6])
-	iris.RawData["population_general_age_0610"] = parseFloat(record[7])
-	iris.RawData["population_general_age_1117"] = parseFloat(record[8])
-	iris.RawData["population_general_age_1824"] = parseFloat(record[9])
-	iris.RawData["population_general_age_2539"] = parseFloat(record[10])
-	iris.RawData["population_general_age_4054"] = parseFloat(record[11])
-	iris.RawData["population_general_age_5564"] = parseFloat(record[12])
-	iris.RawData["population_general_age_6579"] = parseFloat(record[13])
-	iris.RawData["population_general_age_80P"] = parseFloat(record[14])
-	iris.RawData["population_total_age_0014"] = parseFloat(record[15])
-	iris.RawData["population_total_age_1529"] = parseFloat(record[16])
-	iris.RawData["population_total_age_3044"] = parseFloat(record[17])
-	iris.RawData["population_total_age_4559"] = parseFloat(record[18])
-	iris.RawData["population_total_age_6074"] = parseFloat(record[19])
-	iris.RawData["population_total_age_75P"] = parseFloat(record[20])
-	iris.RawData["population_total_age_0019"] = parseFloat(record[21])
-	iris.RawData["population_total_age_2064"] = parseFloat(record[22])
-	iris.RawData["population_total_age_65P"] = parseFloat(record[23])
-	iris.RawData["population_male"] = parseFloat(record[24])
-	iris.RawData["population_male_age_0014"] = parseFloat(record[25])
-	iris.RawData["population_male_age_1529"] = parseFloat(record[26])
-	iris.RawData["population_male_age_3044"] = parseFloat(record[27])
-	iris.RawData["population_male_age_4559"] = parseFloat(record[28])
-	iris.RawData["population_male_age_6074"] = parseFloat(record[29])
-	iris.RawData["population_male_age_75P"] = parseFloat(record[30])
-	iris.RawData["population_male_age_0019"] = parseFloat(record[31])
-	iris.RawData["population_male_age_2064"] = parseFloat(record[32])
-	iris.RawData["population_male_age_65P"] = parseFloat(record[33])
-	iris.RawData["population_female"] = parseFloat(record[34])
-	iris.RawData["population_female_age_0014"] = parseFloat(record[35])
-	iris.RawData["population_female_age_1529"] = parseFloat(record[36])
-	iris.RawData["population_female_age_3044"] = parseFloat(record[37])
-	iris.RawData["population_female_age_4559"] = parseFloat(record[38])
-	iris.RawData["population_female_age_6074"] = parseFloat(record[39])
-	iris.RawData["population_female_age_75P"] = parseFloat(record[40])
-	iris.RawData["population_female_age_0019"] = parseFloat(record[41])
-	iris.RawData["population_female_age_2064"] = parseFloat(record[42])
-	iris.RawData["population_female_age_65P"] = parseFloat(record[43])
-	iris.RawData["employees_number"] = parseFloat(record[44])
-	iris.RawData["employees_category_1"] = parseFloat(record[45])
-	iris.RawData["employees_category_2"] = parseFloat(record[46])
-	iris.RawData["employees_category_3"] = parseFloat(record[47])
-	iris.RawData["employees_category_4"] = parseFloat(record[48])
-	iris.RawData["employees_category_5"] = parseFloat(record[49])
-	iris.RawData["employees_category_6"] = parseFloat(record[50])
-	iris.RawData["employees_category_7"] = parseFloat(record[51])
-	iris.RawData["employees_category_8"] = parseFloat(record[52])
-	iris.RawData["employees_male"] = parseFloat(record[53])
-	iris.RawData["employees_male_category_1"] = parseFloat(record[54])
-	iris.RawData["employees_male_category_2"] = parseFloat(record[55])
-	iris.RawData["employees_male_category_3"] = parseFloat(record[56])
-	iris.RawData["employees_male_category_4"] = parseFloat(record[57])
-	iris.RawData["employees_male_category_5"] = parseFloat(record[58])
-	iris.RawData["employees_male_category_6"] = parseFloat(record[59])
-	iris.RawData["employees_male_category_7"] = parseFloat(record[60])
-	iris.RawData["employees_male_category_8"] = parseFloat(record[61])
-	iris.RawData["employees_female"] = parseFloat(record[62])
-	iris.RawData["employees_female_category_1"] = parseFloat(record[63])
-	iris.RawData["employees_female_category_2"] = parseFloat(record[64])
-	iris.RawData["employees_female_category_3"] = parseFloat(record[65])
-	iris.RawData["employees_female_category_4"] = parseFloat(record[66])
-	iris.RawData["employees_female_category_5"] = parseFloat(record[67])
-	iris.RawData["employees_female_category_6"] = parseFloat(record[68])
-	iris.RawData["employees_female_category_7"] = parseFloat(record[69])
-	iris.RawData["employees_female_category_8"] = parseFloat(record[70])
-	iris.RawData["population_french"] = parseFloat(record[71])
-	iris.RawData["population_foreign"] = parseFloat(record[72])
-	iris.RawData["population_immigrant"] = parseFloat(record[73])
-	iris.RawData["housing_people_per_home"] = parseFloat(record[74]) // this should be fixed, people in households
-	iris.RawData["housing_people_in_collective_housing"] = parseFloat(record[75])
-
-	// Find the polygon column (column 77)
-	polygonStr := record[76] // POLYGON
+	iris.IRIS = strings.Clone(cols.get(record, "iris"))
+	iris.COM = strings.Clone(cols.get(record, "commune"))
+	iris.TYP_IRIS = strings.Clone(cols.get(record, "type"))
+	iris.LAB_IRIS = strings.Clone(cols.get(record, "label"))
+	for _, statistic := range statistics {
+		iris.RawData[statistic.key] = parseFloat(valueAt(record, statistic.index))
+	}
+
+	polygonStr := cols.get(record, "polygon")
 	if polygonStr == "" {
 		return nil
 	}
@@ -977,50 +824,9 @@ func (s *CSVService) parseIrisRecord(record []string) *models.IrisData {
 	if iris.Polygon == nil {
 		return nil
 	}
+	iris.ProjectedPolygon = projectPolygon(iris.Polygon)
 
-	iris.Area = parseFloat(record[77]) // AREA
-
-	iris.RawData["families_only_number"] = parseFloat(record[78])
-	iris.RawData["families_with_kids"] = parseFloat(record[79])
-	iris.RawData["families_monoparental"] = parseFloat(record[80])
-	iris.RawData["families_without_kids"] = parseFloat(record[81])
-	iris.RawData["families_with_1_kids_under_25"] = parseFloat(record[82])
-	iris.RawData["families_with_2_kids_under_25"] = parseFloat(record[83])
-	iris.RawData["families_with_3_kids_under_25"] = parseFloat(record[84])
-	iris.RawData["families_with_4p_kids_under_25"] = parseFloat(record[85])
-	iris.RawData["families_number"] = parseFloat(record[86])
-	iris.RawData["families_one_person"] = parseFloat(record[87])
-	iris.RawData["families_living_without_family"] = parseFloat(record[88])
-	iris.RawData["families_living_with_family"] = parseFloat(record[89])
-	iris.RawData["employees_number"] = parseFloat(record[90])
-	iris.RawData["students_number"] = parseFloat(record[91])
-	iris.RawData["housing_total"] = parseFloat(record[92])
-	iris.RawData["housing_primary_residence"] = parseFloat(record[93])
-	iris.RawData["housing_secondary_residence"] = parseFloat(record[94])
-	iris.RawData["housing_empty_residence"] = parseFloat(record[95])
-	iris.RawData["housing_houses"] = parseFloat(record[96])
-	iris.RawData["housing_apartments"] = parseFloat(record[97])
-	iris.RawData["housing_rooms_1_rooms"] = parseFloat(record[98])
-	iris.RawData["housing_rooms_2_rooms"] = parseFloat(record[99])
-	iris.RawData["housing_rooms_3_rooms"] = parseFloat(record[100])
-	iris.RawData["housing_rooms_4_rooms"] = parseFloat(record[101])
-	iris.RawData["housing_rooms_5p_rooms"] = parseFloat(record[102])
-	iris.RawData["housing_houses_constructed_before_19"] = parseFloat(record[103])
-	iris.RawData["housing_houses_constructed_19_45"] = parseFloat(record[104])
-	iris.RawData["housing_houses_constructed_46_70"] = parseFloat(record[105])
-	iris.RawData["housing_houses_constructed_71_90"] = parseFloat(record[106])
-	iris.RawData["housing_houses_constructed_91_05"] = parseFloat(record[107])
-	iris.RawData["housing_houses_constructed_06_17"] = parseFloat(record[108])
-	iris.RawData["housing_moved_since_0_2_years"] = parseFloat(record[109])
-	iris.RawData["housing_moved_since_2_4_years"] = parseFloat(record[110])
-	iris.RawData["housing_moved_since_5_9_years"] = parseFloat(record[111])
-	iris.RawData["housing_moved_since_10p_years"] = parseFloat(record[112])
-	iris.RawData["housing_owners"] = parseFloat(record[113])
-	iris.RawData["housing_renters"] = parseFloat(record[114])
-	iris.RawData["housing_with_parkings"] = parseFloat(record[115])
-	iris.RawData["housing_with_atleast_1_cars"] = parseFloat(record[116])
-	iris.RawData["housing_with_1_cars"] = parseFloat(record[117])
-	iris.RawData["housing_with_2p_cars"] = parseFloat(record[118])
+	iris.Area = parseFloat(cols.get(record, "area"))
 
 	// Set total population
 	iris.TotalPopulation = iris.RawData["population_total"]
@@ -1028,13 +834,39 @@ func (s *CSVService) parseIrisRecord(record []string) *models.IrisData {
 	return iris
 }
 
-func (s *CSVService) GetCompetitionData(businesses []*models.Business) (*models.CompetitionResponseByNAF, error) {
-	if err := s.competitionService.doLoadCompetitionData(businesses); err != nil {
+// GetCompetitionData loads and aggregates the competition data of the given businesses
+func (s *CSVService) GetCompetitionData(ctx context.Context, businesses []*models.Business) (*models.CompetitionResponseByNAF, error) {
+	if err := s.loadCompetitionData(ctx, businesses); err != nil {
 		return nil, err
 	}
 	return s.competitionService.GetCompetitionData(businesses)
 }
 
+// GetCompetitorDetails returns every business along with its financial data, which is nil
+// when the competition file has no entry for the business
+func (s *CSVService) GetCompetitorDetails(ctx context.Context, businesses []*models.Business) ([]models.CompetitorDetails, error) {
+	if err := s.loadCompetitionData(ctx, businesses); err != nil {
+		return nil, err
+	}
+	return s.competitionService.GetCompetitorDetails(businesses), nil
+}
+
+// loadCompetitionData reads the competition data of the given businesses
+func (s *CSVService) loadCompetitionData(ctx context.Context, businesses []*models.Business) error {
+	stats, err := s.competitionService.doLoadCompetitionData(ctx, businesses)
+	if err != nil {
+		var serviceErr *ServiceError
+		if errors.As(err, &serviceErr) {
+			return serviceErr
+		}
+		return newServiceError(ErrorDataUnavailable, "Competition data is unavailable", err)
+	}
+	s.recordLoad(DatasetCompetition, stats)
+	// The competition file is read on demand, by a generation that already serves requests
+	stats.publish(DatasetCompetition)
+	return nil
+}
+
 // Helper function to parse float values
 func parseFloat(s string) float64 {
 	f, _ := strconv.ParseFloat(s, 64)
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"os"
+	"time"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/metrics"
+
+	"golang.org/x/exp/slices"
+)
+
+// Dataset names, matching the keys of the CSV configuration
+const (
+	DatasetBusinesses       = "business_data"
+	DatasetCompetition      = "competition_data"
+	DatasetCommuneCrimes    = "commune_crimes"
+	DatasetDepartmentCrimes = "department_crimes"
+	DatasetIris             = "iris_data"
+	DatasetCommunes         = "commune_data"
+	DatasetQP               = "qp_data"
+)
+
+// requiredDatasets are the datasets a generation cannot serve requests without.
+// The crime files are optional, the criminality service being left out without them.
+var requiredDatasets = []string{DatasetBusinesses, DatasetCompetition, DatasetIris, DatasetCommunes, DatasetQP}
+
+// loadStats counts the rows kept and skipped while parsing a CSV file
+type loadStats struct {
+	rows     int
+	skipped  int
+	bytes    int // memory held by the parsed rows, 0 when not measured
+	duration time.Duration
+	loadedAt time.Time
+}
+
+// publish sets the load metrics of a dataset
+func (stats loadStats) publish(name string) {
+	metrics.CSVLoadDuration.Set(stats.duration.Seconds(), name)
+	metrics.DatasetRows.Set(float64(stats.rows), name)
+	if stats.bytes > 0 {
+		metrics.DatasetBytes.Set(float64(stats.bytes), name)
+	}
+}
+
+// DatasetInfo describes a configured CSV file, how it was loaded and whether the service using it is active
+type DatasetInfo struct {
+	Name          string     `json:"name"`
+	Path          string     `json:"path"`
+	Exists        bool       `json:"exists"`
+	SizeBytes     int64      `json:"size_bytes"`
+	ModifiedAt    *time.Time `json:"modified_at,omitempty"`
+	Loaded        bool       `json:"loaded"`
+	LoadedAt      *time.Time `json:"loaded_at,omitempty"`
+	Rows          int        `json:"rows"`
+	SkippedRows   int        `json:"skipped_rows"`
+	Service       string     `json:"service"`
+	ServiceActive bool       `json:"service_active"`
+	Required      bool       `json:"required"`
+}
+
+// configuredDataset is a CSV file of the configuration, its delimiter and the service depending on it
+type configuredDataset struct {
+	name     string
+	fileName string
+	comma    rune
+	service  string
+}
+
+// configuredDatasets lists every CSV file of a configuration
+func configuredDatasets(csvConfig config.CSVConfig) []configuredDataset {
+	return []configuredDataset{
+		{DatasetBusinesses, csvConfig.BusinessData, ',', "business_search"},
+		{DatasetCompetition, csvConfig.CompetitionData, ';', "competition"},
+		{DatasetCommuneCrimes, csvConfig.CommuneCrimes, ';', "criminality"},
+		{DatasetDepartmentCrimes, csvConfig.DepartmentCrimes, ';', "criminality"},
+		{DatasetIris, csvConfig.IrisData, ';', "iris"},
+		{DatasetCommunes, csvConfig.CommuneData, ';', "iris"},
+		{DatasetQP, csvConfig.QPData, ';', "iris"},
+	}
+}
+
+// recordLoad stores the statistics of a dataset once it has been parsed
+func (s *CSVService) recordLoad(name string, stats loadStats) {
+	s.loadStatsMu.Lock()
+	defer s.loadStatsMu.Unlock()
+	if s.loadStats == nil {
+		s.loadStats = make(map[string]loadStats)
+	}
+	stats.loadedAt = time.Now()
+	s.loadStats[name] = stats
+}
+
+// publishLoadMetrics exposes the load statistics of the datasets. It is called once the generation
+// serves requests, so the metrics never describe a generation that was rejected.
+func (s *CSVService) publishLoadMetrics() {
+	s.loadStatsMu.Lock()
+	defer s.loadStatsMu.Unlock()
+	for name, stats := range s.loadStats {
+		stats.publish(name)
+	}
+}
+
+// serviceActive reports whether the service using a dataset is running
+func (s *CSVService) serviceActive(service string) bool {
+	switch service {
+	case "business_search":
+		return s.businessStore != nil
+	case "competition":
+		return s.competitionService != nil
+	case "criminality":
+		return s.criminalityService != nil
+	case "iris":
+		return s.geoLayers != nil
+	}
+	return false
+}
+
+// DescribeDatasets returns the file information of every dataset of the given generation, along with
+// its load statistics, or of the current configuration when s is nil. Competition data is read on
+// demand, so its statistics come from the last request that used it.
+func DescribeDatasets(s *CSVService) []DatasetInfo {
+	csvConfig := config.GetCSVConfig()
+	if s != nil {
+		csvConfig = s.csvConfig
+	}
+	datasets := configuredDatasets(csvConfig)
+	infos := make([]DatasetInfo, 0, len(datasets))
+	for _, dataset := range datasets {
+		info := DatasetInfo{
+			Name:     dataset.name,
+			Path:     config.GetDataFilePath(dataset.fileName),
+			Service:  dataset.service,
+			Required: slices.Contains(requiredDatasets, dataset.name),
+		}
+
+		if stat, err := os.Stat(info.Path); err == nil {
+			modifiedAt := stat.ModTime()
+			info.Exists = true
+			info.SizeBytes = stat.Size()
+			info.ModifiedAt = &modifiedAt
+		}
+
+		if s != nil {
+			s.loadStatsMu.Lock()
+			stats, loaded := s.loadStats[dataset.name]
+			s.loadStatsMu.Unlock()
+			if loaded {
+				info.Loaded = true
+				info.LoadedAt = &stats.loadedAt
+				info.Rows = stats.rows
+				info.SkippedRows = stats.skipped
+			}
+			info.ServiceActive = s.serviceActive(dataset.service) && info.Exists
+		}
+
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorKind classifies service errors so callers can tell them apart
+type ErrorKind string
+
+const (
+	// ErrorNotFound means the request is valid but nothing matches it
+	ErrorNotFound ErrorKind = "not_found"
+	// ErrorInvalidGeometry means the request geometry cannot be processed
+	ErrorInvalidGeometry ErrorKind = "invalid_geometry"
+	// ErrorInvalidInput means a request parameter other than the geometry is invalid
+	ErrorInvalidInput ErrorKind = "invalid_input"
+	// ErrorConflict means the request conflicts with work already in progress
+	ErrorConflict ErrorKind = "conflict"
+	// ErrorDataUnavailable means a dataset needed by the request cannot be read
+	ErrorDataUnavailable ErrorKind = "data_unavailable"
+	// ErrorTimeout means the request deadline expired before the work was done
+	ErrorTimeout ErrorKind = "timeout"
+	// ErrorCanceled means the client went away before the work was done
+	ErrorCanceled ErrorKind = "canceled"
+	// ErrorInternal is any other failure
+	ErrorInternal ErrorKind = "internal_error"
+)
+
+// ServiceError is an error returned by the services with its kind.
+// Message is meant for clients, Err keeps the underlying cause for the logs.
+type ServiceError struct {
+	Kind    ErrorKind
+	Message string
+	Details map[string]interface{}
+	Err     error
+}
+
+// newServiceError creates a service error of the given kind wrapping an optional cause
+func newServiceError(kind ErrorKind, message string, err error) *ServiceError {
+	return &ServiceError{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// Error implements the error interface
+func (e *ServiceError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetail adds a detail to the error and returns it
+func (e *ServiceError) WithDetail(key string, value interface{}) *ServiceError {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// contextError converts the error of a done context to a service error.
+// The stage detail tells which part of the work was interrupted.
+func contextError(ctx context.Context, stage string) *ServiceError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newServiceError(ErrorTimeout, "Request deadline exceeded", ctx.Err()).WithDetail("stage", stage)
+	}
+	return newServiceError(ErrorCanceled, "Request canceled", ctx.Err()).WithDetail("stage", stage)
+}
+
+// ErrorKindOf returns the kind of a service error, or ErrorInternal for any other error
+func ErrorKindOf(err error) ErrorKind {
+	var serviceErr *ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind
+	}
+	return ErrorInternal
+}
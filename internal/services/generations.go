@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"csv-processor/internal/config"
+	"csv-processor/internal/metrics"
+)
+
+// Reload triggers
+const (
+	ReloadTriggerStartup = "startup"
+	ReloadTriggerAdmin   = "admin"
+	ReloadTriggerWatcher = "watcher"
+)
+
+// ReloadStatus describes the current generation and the last load of a new one
+type ReloadStatus struct {
+	Generation int        `json:"generation"`
+	Reloading  bool       `json:"reloading"`
+	Trigger    string     `json:"trigger,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Datasets holds the generation of loaded datasets serving requests. New generations are
+// loaded in the background, validated and swapped in atomically, while requests keep the
+// generation they started with until they finish.
+type Datasets struct {
+	current    atomic.Pointer[CSVService]
+	workerPool *WorkerPool
+
+	// ctx is canceled by Close to stop a running reload and the watcher
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	status    ReloadStatus
+	seenFiles string // state of the data files at the start of the last load
+}
+
+// NewDatasets creates an empty Datasets whose generations share the given worker pool
+func NewDatasets(workerPool *WorkerPool) *Datasets {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Datasets{
+		workerPool: workerPool,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Current returns the generation serving requests, or nil until the first one is loaded
+func (d *Datasets) Current() *CSVService {
+	return d.current.Load()
+}
+
+// Service returns the generation serving requests, or a data unavailable error until the first one is loaded
+func (d *Datasets) Service() (*CSVService, error) {
+	csvService := d.current.Load()
+	if csvService == nil {
+		return nil, newServiceError(ErrorDataUnavailable, "Datasets are still loading", nil)
+	}
+	return csvService, nil
+}
+
+// Status returns the current generation and the state of the last reload
+func (d *Datasets) Status() ReloadStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// Load loads the first generation and makes it current. Loading stops early when ctx is done.
+func (d *Datasets) Load(ctx context.Context) error {
+	if !d.begin(ReloadTriggerStartup) {
+		return newServiceError(ErrorConflict, "Datasets are already loading", nil)
+	}
+	err := d.load(ctx)
+	d.finish(ReloadTriggerStartup, err)
+	return err
+}
+
+// Reload rereads the configuration and loads a new generation in the background.
+// It returns a conflict error when a load is already running.
+func (d *Datasets) Reload(trigger string) error {
+	if !d.begin(trigger) {
+		return newServiceError(ErrorConflict, "A dataset reload is already running", nil)
+	}
+
+	go func() {
+		var err error
+		if err = config.Reload(); err == nil {
+			err = d.load(d.ctx)
+		}
+		d.finish(trigger, err)
+	}()
+	return nil
+}
+
+// Watch polls the data files and the configuration file every interval and reloads the
+// datasets once a change has stayed the same for a full interval, so that files still
+// being copied are not loaded. It stops when the Datasets are closed.
+func (d *Datasets) Watch(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		pending := ""
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			files := dataFilesState()
+			d.mu.Lock()
+			seenFiles := d.seenFiles
+			d.mu.Unlock()
+			if files == seenFiles {
+				pending = ""
+				continue
+			}
+			if files != pending {
+				pending = files
+				continue
+			}
+
+			log.Printf("Data files changed, reloading datasets")
+			if err := d.Reload(ReloadTriggerWatcher); err != nil {
+				// Try again on the next tick once the running reload is done
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			pending = ""
+		}
+	}()
+}
+
+// Close stops a running reload and the watcher. The current generation keeps serving requests.
+func (d *Datasets) Close() {
+	d.cancel()
+}
+
+// begin marks a load as running, returning false when one already is
+func (d *Datasets) begin(trigger string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.status.Reloading {
+		return false
+	}
+	startedAt := time.Now()
+	d.status.Reloading = true
+	d.status.Trigger = trigger
+	d.status.StartedAt = &startedAt
+	d.status.FinishedAt = nil
+	d.status.Error = ""
+	return true
+}
+
+// finish records the outcome of a load
+func (d *Datasets) finish(trigger string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	finishedAt := time.Now()
+	d.status.Reloading = false
+	d.status.FinishedAt = &finishedAt
+	if err != nil {
+		d.status.Error = err.Error()
+		metrics.DatasetReloads.Inc(trigger, "failure")
+		if d.status.Generation > 0 {
+			log.Printf("Warning: dataset reload (%s) failed, generation %d keeps serving requests: %v", trigger, d.status.Generation, err)
+		}
+		return
+	}
+	metrics.DatasetReloads.Inc(trigger, "success")
+}
+
+// load loads a generation from the current configuration, validates it and makes it current
+func (d *Datasets) load(ctx context.Context) error {
+	// Record the files before reading them, so a change during the load triggers another one
+	// and a failed load is not retried until the files change again
+	d.mu.Lock()
+	d.seenFiles = dataFilesState()
+	d.mu.Unlock()
+
+	startTime := time.Now()
+	next, err := NewCSVService(ctx, d.workerPool)
+	if err != nil {
+		return err
+	}
+	if err := validateGeneration(next, d.current.Load()); err != nil {
+		return fmt.Errorf("invalid datasets: %v", err)
+	}
+
+	d.mu.Lock()
+	d.status.Generation++
+	next.generation = d.status.Generation
+	d.current.Store(next)
+	d.mu.Unlock()
+
+	metrics.DatasetGeneration.Set(float64(next.generation))
+	next.publishLoadMetrics()
+	log.Printf("Dataset generation %d loaded in %v", next.generation, time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
+
+// validateGeneration checks that a new generation can replace the current one, which may be nil.
+// Required datasets must be present and not empty, and every service active in the current
+// generation must stay active.
+func validateGeneration(next, current *CSVService) error {
+	infos := make(map[string]DatasetInfo)
+	for _, info := range DescribeDatasets(next) {
+		infos[info.Name] = info
+	}
+
+	for _, name := range requiredDatasets {
+		info := infos[name]
+		if !info.Exists {
+			return fmt.Errorf("%s file not found at: %s", name, info.Path)
+		}
+		// Competition data is read on demand and has no rows yet
+		if name != DatasetCompetition && info.Rows == 0 {
+			return fmt.Errorf("%s has no rows", name)
+		}
+	}
+
+	if current != nil {
+		for _, info := range DescribeDatasets(current) {
+			if info.ServiceActive && !infos[info.Name].ServiceActive {
+				return fmt.Errorf("%s would become inactive", info.Service)
+			}
+		}
+	}
+	return nil
+}
+
+// dataFilesState summarizes the size and modification time of the configuration file and of
+// every dataset file of the current configuration, so that changes can be detected
+func dataFilesState() string {
+	paths := []string{config.ConfigFile}
+	for _, dataset := range configuredDatasets(config.GetCSVConfig()) {
+		paths = append(paths, config.GetDataFilePath(dataset.fileName))
+	}
+
+	var state strings.Builder
+	for _, path := range paths {
+		if stat, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&state, "%s:%d:%d;", path, stat.Size(), stat.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(&state, "%s:missing;", path)
+		}
+	}
+	return state.String()
+}
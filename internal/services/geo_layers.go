@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// qpZone is a Quartier Prioritaire with its polygon
+type qpZone struct {
+	ID      string
+	CodeQP  string
+	LibQP   string
+	Commune string
+	Polygon *geom2.Geometry
+	// Lambert-93 polygon, for area calculations
+	ProjectedPolygon *geom2.Geometry
+}
+
+// GeoLayers holds the IRIS, commune and QP polygons parsed once at startup,
+// along with their Lambert-93 projection used for area calculations.
+// Each layer has a bounding-box index so a request only runs exact
+// intersections on the zones whose envelope overlaps the request polygon.
+type GeoLayers struct {
+	iris       []*models.IrisData
+	irisByCode map[string]*models.IrisData
+	irisIndex  *models.EnvelopeIndex
+
+	communes             []*models.CommuneData
+	communesByCode       map[string]*models.CommuneData
+	communesByPostalCode map[string][]*models.CommuneData
+	communesByDepartment map[string][]*models.CommuneData
+	communeIndex         *models.EnvelopeIndex
+
+	qps      []*qpZone
+	qpByCode map[string]*qpZone
+	qpIndex  *models.EnvelopeIndex
+
+	// Administrative areas resolved so far, by areaKey
+	areas sync.Map
+}
+
+// projectPolygon returns the polygon projected to Lambert-93, or nil when there is no polygon
+func projectPolygon(polygon *geom2.Geometry) *geom2.Geometry {
+	if polygon == nil {
+		return nil
+	}
+	projected := models.ProjectToLambert93(*polygon)
+	return &projected
+}
+
+// loadGeoLayers loads and indexes the IRIS, commune and QP CSV files
+func (s *CSVService) loadGeoLayers(ctx context.Context) (*GeoLayers, error) {
+	layers := &GeoLayers{}
+
+	startTime := time.Now()
+	irisData, err := s.loadIrisData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading IRIS data: %v", err)
+	}
+	layers.iris = irisData
+	layers.irisByCode = make(map[string]*models.IrisData, len(irisData))
+	for _, iris := range irisData {
+		layers.irisByCode[iris.IRIS] = iris
+	}
+	layers.irisIndex = models.NewEnvelopeIndex(polygonEnvelopes(len(irisData), func(i int) *geom2.Geometry { return irisData[i].Polygon }))
+	log.Printf("Loaded %d IRIS zones in %v", len(irisData), time.Since(startTime).Round(time.Millisecond))
+
+	startTime = time.Now()
+	communeData, err := s.loadCommuneData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading commune data: %v", err)
+	}
+	layers.communes = communeData
+	layers.communesByCode = make(map[string]*models.CommuneData, len(communeData))
+	layers.communesByPostalCode = make(map[string][]*models.CommuneData)
+	layers.communesByDepartment = make(map[string][]*models.CommuneData)
+	for _, commune := range communeData {
+		layers.communesByCode[commune.CommuneCode] = commune
+		// A commune may have several postal codes, separated by commas
+		for postalCode := range strings.SplitSeq(commune.PostalCode, ",") {
+			if postalCode = strings.TrimSpace(postalCode); postalCode != "" {
+				layers.communesByPostalCode[postalCode] = append(layers.communesByPostalCode[postalCode], commune)
+			}
+		}
+		department := departmentCode(commune.CommuneCode)
+		layers.communesByDepartment[department] = append(layers.communesByDepartment[department], commune)
+	}
+	layers.communeIndex = models.NewEnvelopeIndex(polygonEnvelopes(len(communeData), func(i int) *geom2.Geometry { return communeData[i].Polygon }))
+	log.Printf("Loaded %d communes in %v", len(communeData), time.Since(startTime).Round(time.Millisecond))
+
+	startTime = time.Now()
+	qpData, err := s.loadQPData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading QP data: %v", err)
+	}
+	layers.qps = qpData
+	layers.qpByCode = make(map[string]*qpZone, len(qpData))
+	for _, qp := range qpData {
+		layers.qpByCode[strings.ToUpper(qp.CodeQP)] = qp
+	}
+	layers.qpIndex = models.NewEnvelopeIndex(polygonEnvelopes(len(qpData), func(i int) *geom2.Geometry { return qpData[i].Polygon }))
+	log.Printf("Loaded %d QP zones in %v", len(qpData), time.Since(startTime).Round(time.Millisecond))
+
+	return layers, nil
+}
+
+// polygonEnvelopes returns the envelope of each polygon, empty when the polygon is missing
+func polygonEnvelopes(n int, polygon func(int) *geom2.Geometry) []models.Envelope {
+	envelopes := make([]models.Envelope, n)
+	for i := range envelopes {
+		if p := polygon(i); p != nil {
+			envelopes[i] = models.GeometryEnvelope(*p)
+		} else {
+			envelopes[i] = models.EmptyEnvelope()
+		}
+	}
+	return envelopes
+}
+
+// irisCandidates returns the IRIS zones whose envelope overlaps the given envelope
+func (l *GeoLayers) irisCandidates(envelope models.Envelope) []*models.IrisData {
+	positions := l.irisIndex.Search(envelope)
+	candidates := make([]*models.IrisData, len(positions))
+	for i, position := range positions {
+		candidates[i] = l.iris[position]
+	}
+	return candidates
+}
+
+// communeCandidates returns the communes whose envelope overlaps the given envelope
+func (l *GeoLayers) communeCandidates(envelope models.Envelope) []*models.CommuneData {
+	positions := l.communeIndex.Search(envelope)
+	candidates := make([]*models.CommuneData, len(positions))
+	for i, position := range positions {
+		candidates[i] = l.communes[position]
+	}
+	return candidates
+}
+
+// qpCandidates returns the QP zones whose envelope overlaps the given envelope
+func (l *GeoLayers) qpCandidates(envelope models.Envelope) []*qpZone {
+	positions := l.qpIndex.Search(envelope)
+	candidates := make([]*qpZone, len(positions))
+	for i, position := range positions {
+		candidates[i] = l.qps[position]
+	}
+	return candidates
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"log"
+	"sort"
+
+	"csv-processor/internal/models"
+)
+
+// irisBreakdown returns the part of an IRIS zone intersecting the request area, with its
+// statistics weighted and selected the same way as in the aggregated response
+func irisBreakdown(iris *models.IrisData, inclusionPercentage float64, options IrisOptions) models.IrisBreakdown {
+	factor := inclusionPercentage / 100.0
+	weighted := make(map[string]float64, len(iris.RawData))
+	for k, v := range iris.RawData {
+		weighted[k] = v * factor
+	}
+	entry := models.IrisBreakdown{
+		Code:                   iris.IRIS,
+		Label:                  iris.LAB_IRIS,
+		Commune:                iris.COM,
+		IntersectionPercentage: inclusionPercentage,
+		Population:             iris.TotalPopulation * factor,
+		Statistics:             selectStatistics(weighted, options),
+	}
+
+	if options.BreakdownGeometry && iris.Polygon != nil {
+		geometry, err := iris.Polygon.MarshalJSON()
+		if err != nil {
+			log.Printf("Warning: error encoding IRIS %s polygon: %v", iris.IRIS, err)
+		} else {
+			entry.Geometry = geometry
+		}
+	}
+	return entry
+}
+
+// sortBreakdown orders the zones of a breakdown by IRIS code so that responses are stable
+func sortBreakdown(breakdown []models.IrisBreakdown) {
+	sort.Slice(breakdown, func(i, j int) bool {
+		return breakdown[i].Code < breakdown[j].Code
+	})
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"math"
+
+	"csv-processor/internal/models"
+)
+
+// IrisOptions selects the optional parts of an IRIS data response
+type IrisOptions struct {
+	// Breakdown lists every intersecting IRIS zone with its own weighted statistics
+	Breakdown bool
+	// BreakdownGeometry adds the polygon of every zone to the breakdown
+	BreakdownGeometry bool
+	// Metrics are the statistic and indicator keys to return, every statistic when empty
+	Metrics []string
+	// Precision is the number of decimals of the returned values. When nil, statistics are
+	// rounded to integers and indicators to their own precision.
+	Precision *int
+}
+
+// selectStatistics returns the requested statistics and indicators of an area, rounded to the
+// requested precision. values are the weighted sums of the area, from which indicators are computed.
+// Indicators with a zero denominator are left out.
+func selectStatistics(values map[string]float64, options IrisOptions) map[string]float64 {
+	metrics := options.Metrics
+	if len(metrics) == 0 {
+		metrics = make([]string, 0, len(values))
+		for key := range values {
+			metrics = append(metrics, key)
+		}
+	}
+
+	selected := make(map[string]float64, len(metrics))
+	for _, key := range metrics {
+		value, precision := values[key], 0
+		if indicator := models.IrisIndicatorByKey(key); indicator != nil {
+			var defined bool
+			if value, defined = indicator.Compute(values); !defined {
+				continue
+			}
+			precision = indicator.Precision
+		}
+		if options.Precision != nil {
+			precision = *options.Precision
+		}
+		selected[key] = roundTo(value, precision)
+	}
+	return selected
+}
+
+// roundTo rounds a value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectStatistics(t *testing.T) {
+	// Weighted sums of an area without dwellings count
+	values := map[string]float64{
+		"population_total":            1234.567,
+		"population_female":           617.3,
+		"housing_primary_residence":   100,
+		"housing_with_atleast_1_cars": 81.26,
+		"housing_people_per_home":     215.556,
+	}
+	precision := func(decimals int) *int { return &decimals }
+
+	tests := []struct {
+		name    string
+		options IrisOptions
+		want    map[string]float64
+	}{
+		{
+			name:    "every statistic rounded to integers",
+			options: IrisOptions{},
+			want: map[string]float64{
+				"population_total":            1235,
+				"population_female":           617,
+				"housing_primary_residence":   100,
+				"housing_with_atleast_1_cars": 81,
+				"housing_people_per_home":     216,
+			},
+		},
+		{
+			name: "indicators at their own precision",
+			options: IrisOptions{Metrics: []string{
+				"population_total", "share_female", "share_households_without_car", "people_per_dwelling",
+			}},
+			want: map[string]float64{
+				"population_total":             1235,
+				"share_female":                 50.0,
+				"share_households_without_car": 18.7,
+				"people_per_dwelling":          2.16,
+			},
+		},
+		{
+			name: "requested precision",
+			options: IrisOptions{
+				Metrics:   []string{"population_total", "share_female", "people_per_dwelling"},
+				Precision: precision(3),
+			},
+			want: map[string]float64{
+				"population_total":    1234.567,
+				"share_female":        50.001,
+				"people_per_dwelling": 2.156,
+			},
+		},
+		{
+			name: "indicators rounded to integers",
+			options: IrisOptions{
+				Metrics:   []string{"share_households_without_car", "people_per_dwelling"},
+				Precision: precision(0),
+			},
+			want: map[string]float64{
+				"share_households_without_car": 19,
+				"people_per_dwelling":          2,
+			},
+		},
+		{
+			name:    "indicator without denominator",
+			options: IrisOptions{Metrics: []string{"population_total", "share_vacant_dwellings"}},
+			want:    map[string]float64{"population_total": 1235},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectStatistics(values, tt.options)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectStatistics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		want     float64
+	}{
+		{2.5, 0, 3},
+		{-2.5, 0, -3},
+		{1.2345, 2, 1.23},
+		{1234.5, 0, 1235},
+	}
+	for _, tt := range tests {
+		if got := roundTo(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("roundTo(%v, %d) = %v, want %v", tt.value, tt.decimals, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"math"
+	"strings"
+
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// LocateStatistics are the statistics of the IRIS zone returned by Locate
+var LocateStatistics = []string{
+	"population_total",
+	"population_total_age_0019",
+	"population_total_age_2064",
+	"population_total_age_65P",
+	"families_number",
+	"housing_total",
+	"housing_owners",
+	"housing_renters",
+	"employees_number",
+	"students_number",
+}
+
+// containsPoint reports whether a zone polygon contains the point
+func containsPoint(polygon *geom2.Geometry, lng, lat float64) bool {
+	return polygon != nil && models.NewPolygonSet(*polygon).ContainsPoint(lng, lat)
+}
+
+// Locate returns the IRIS zone, commune, department and QP zones containing a point,
+// looked up in the same layers as GetIrisData
+func (s *CSVService) Locate(point models.Point) *models.LocateResponse {
+	response := &models.LocateResponse{
+		Point:        point,
+		SpecialZones: []models.LocatedQP{},
+	}
+	envelope := models.EmptyEnvelope()
+	envelope.Extend(point.Lng, point.Lat)
+
+	for _, iris := range s.geoLayers.irisCandidates(envelope) {
+		if !containsPoint(iris.Polygon, point.Lng, point.Lat) {
+			continue
+		}
+		located := &models.LocatedIris{
+			Code:       iris.IRIS,
+			Label:      iris.LAB_IRIS,
+			Type:       iris.TYP_IRIS,
+			Statistics: make(map[string]float64, len(LocateStatistics)),
+		}
+		if iris.ProjectedPolygon != nil {
+			located.AreaKm2 = iris.ProjectedPolygon.Area() / 1e6
+		}
+		if located.AreaKm2 > 0 {
+			located.PopulationDensity = iris.TotalPopulation / located.AreaKm2
+		}
+		for _, key := range LocateStatistics {
+			located.Statistics[key] = math.Round(iris.RawData[key])
+		}
+		response.Iris = located
+		break
+	}
+
+	var commune *models.CommuneData
+	for _, candidate := range s.geoLayers.communeCandidates(envelope) {
+		if containsPoint(candidate.Polygon, point.Lng, point.Lat) {
+			commune = candidate
+			break
+		}
+	}
+	// Fall back on the commune of the IRIS zone when the commune polygons leave a gap
+	if commune == nil && response.Iris != nil {
+		commune = s.geoLayers.communesByCode[s.geoLayers.irisByCode[response.Iris.Code].COM]
+	}
+	if commune != nil {
+		located := &models.LocatedCommune{
+			CommuneCode:   commune.CommuneCode,
+			CommuneName:   commune.CommuneName,
+			PostalCodes:   []string{},
+			AverageIncome: commune.AverageIncome,
+		}
+		for postalCode := range strings.SplitSeq(commune.PostalCode, ",") {
+			if postalCode = strings.TrimSpace(postalCode); postalCode != "" {
+				located.PostalCodes = append(located.PostalCodes, postalCode)
+			}
+		}
+		response.Commune = located
+		response.Department = departmentCode(commune.CommuneCode)
+	}
+
+	for _, qp := range s.geoLayers.qpCandidates(envelope) {
+		if containsPoint(qp.Polygon, point.Lng, point.Lat) {
+			response.SpecialZones = append(response.SpecialZones, models.LocatedQP{
+				CodeQP:  qp.CodeQP,
+				LibQP:   qp.LibQP,
+				Commune: qp.Commune,
+			})
+		}
+	}
+
+	return response
+}
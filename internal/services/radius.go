@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	"csv-processor/internal/models"
+)
+
+const (
+	// earthRadiusMeters is the mean Earth radius used for geodesic calculations
+	earthRadiusMeters = 6371008.8
+	// circleSegments is the number of vertices of the polygon approximating a circle
+	circleSegments = 64
+)
+
+// haversineDistance returns the great-circle distance in meters between two points
+func haversineDistance(a, b models.Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// destinationPoint returns the point reached by travelling the given distance in meters
+// from the start point along the given bearing in radians
+func destinationPoint(start models.Point, distance, bearing float64) models.Point {
+	lat1 := start.Lat * math.Pi / 180
+	lng1 := start.Lng * math.Pi / 180
+	angular := distance / earthRadiusMeters
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(bearing))
+	lng2 := lng1 + math.Atan2(math.Sin(bearing)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+
+	return models.Point{
+		Lat: lat2 * 180 / math.Pi,
+		Lng: lng2 * 180 / math.Pi,
+	}
+}
+
+// radiusEnvelope returns the bounding box of the circle of the given radius in meters
+func radiusEnvelope(center models.Point, radius float64) models.Envelope {
+	north := destinationPoint(center, radius, 0)
+	south := destinationPoint(center, radius, math.Pi)
+
+	// The widest longitude span of a small circle is not at the center latitude,
+	// so use the exact half-width instead of the east and west points
+	angular := radius / earthRadiusMeters
+	dLng := 180.0
+	if ratio := math.Sin(angular) / math.Cos(center.Lat*math.Pi/180); ratio < 1 {
+		dLng = math.Asin(ratio) * 180 / math.Pi
+	}
+
+	return models.Envelope{
+		MinX: center.Lng - dLng,
+		MinY: south.Lat,
+		MaxX: center.Lng + dLng,
+		MaxY: north.Lat,
+	}
+}
+
+// withinRadius returns the test of whether a position lies within the given distance in meters of the center
+func withinRadius(center models.Point, radius float64) func(lng, lat float64) bool {
+	return func(lng, lat float64) bool {
+		return haversineDistance(center, models.Point{Lat: lat, Lng: lng}) <= radius
+	}
+}
+
+// GeodesicCircle returns a GeoJSON polygon approximating the circle of the given radius in meters
+func GeodesicCircle(center models.Point, radius float64) *models.GeoJSONGeometry {
+	ring := make([][]float64, 0, circleSegments+1)
+	for i := 0; i < circleSegments; i++ {
+		// Walk counterclockwise, as RFC 7946 recommends for exterior rings
+		bearing := -2 * math.Pi * float64(i) / circleSegments
+		point := destinationPoint(center, radius, bearing)
+		ring = append(ring, []float64{point.Lng, point.Lat})
+	}
+	ring = append(ring, ring[0])
+
+	return &models.GeoJSONGeometry{
+		Type:        "Polygon",
+		Coordinates: [][][][]float64{{ring}},
+	}
+}
+
+// SearchBusinessesInRadius searches for businesses within the given distance in meters of the center
+func (s *CSVService) SearchBusinessesInRadius(ctx context.Context, center models.Point, radius float64, nafCodes []string) ([]*models.Business, error) {
+	if radius <= 0 {
+		return nil, newServiceError(ErrorInvalidInput, "Radius must be positive", nil).WithDetail("radius", radius)
+	}
+
+	if ctx.Err() != nil {
+		return nil, contextError(ctx, "search")
+	}
+
+	// Prune with the circle's envelope, then test the distance of each candidate
+	results := s.businessStore.SearchByNAFWithin(radiusEnvelope(center, radius), withinRadius(center, radius), nafCodes)
+
+	return results, nil
+}
+
+// GetIrisDataInRadius retrieves and aggregates IRIS data for the circle of the given radius in meters
+func (s *CSVService) GetIrisDataInRadius(ctx context.Context, center models.Point, radius float64, options IrisOptions) (*models.IrisResponse, error) {
+	if radius <= 0 {
+		return nil, newServiceError(ErrorInvalidInput, "Radius must be positive", nil).WithDetail("radius", radius)
+	}
+
+	geojsonStr, err := json.Marshal(GeodesicCircle(center, radius))
+	if err != nil {
+		return nil, newServiceError(ErrorInternal, "Error creating circle polygon", err)
+	}
+	return s.GetIrisData(ctx, string(geojsonStr), options)
+}
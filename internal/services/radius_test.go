@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"csv-processor/internal/models"
+)
+
+// paris is the center of the radius tests
+var paris = models.Point{Lat: 48.8566, Lng: 2.3522}
+
+func TestHaversineDistance(t *testing.T) {
+	// One degree along a meridian or the equator is a 180th of a great half-circle
+	degree := earthRadiusMeters * math.Pi / 180
+	tests := []struct {
+		name string
+		a, b models.Point
+		want float64
+	}{
+		{"same point", paris, paris, 0},
+		{"one degree of latitude", models.Point{Lat: 45, Lng: 2}, models.Point{Lat: 46, Lng: 2}, degree},
+		{"one degree of longitude on the equator", models.Point{Lat: 0, Lng: 2}, models.Point{Lat: 0, Lng: 3}, degree},
+		{"antipodes", models.Point{Lat: 0, Lng: 0}, models.Point{Lat: 0, Lng: 180}, 180 * degree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haversineDistance(tt.a, tt.b); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("haversineDistance() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchWithinRadius(t *testing.T) {
+	tests := []struct {
+		name   string
+		radius float64
+		margin float64
+	}{
+		{"one kilometer", 1000, 1},
+		{"largest radius", 50000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Businesses just inside and just outside the circle in eight directions, the diagonal
+			// ones testing the corners of the envelope
+			var csv strings.Builder
+			csv.WriteString("siret,denominationUniteLegale,activitePrincipaleEtablissement,longitude,latitude\n")
+			var inside []string
+			for i := 0; i < 8; i++ {
+				bearing := float64(i) * math.Pi / 4
+				for _, distance := range []float64{tt.radius - tt.margin, tt.radius + tt.margin} {
+					point := destinationPoint(paris, distance, bearing)
+					siret := fmt.Sprintf("%014d", csv.Len())
+					fmt.Fprintf(&csv, "%s,BUSINESS %s,47.11F,%.8f,%.8f\n", siret, siret, point.Lng, point.Lat)
+					if distance < tt.radius {
+						inside = append(inside, siret)
+					}
+				}
+			}
+			store := loadBusinesses(t, csv.String())
+
+			got := sirets(store.SearchByNAFWithin(radiusEnvelope(paris, tt.radius), withinRadius(paris, tt.radius), []string{"47.11F"}))
+			if strings.Join(got, ",") != strings.Join(inside, ",") {
+				t.Errorf("search within %v m = %v, want %v", tt.radius, got, inside)
+			}
+		})
+	}
+}
+
+func TestGeodesicCircle(t *testing.T) {
+	const radius = 5000
+	circle := GeodesicCircle(paris, radius)
+	if circle.Type != "Polygon" || len(circle.Coordinates) != 1 || len(circle.Coordinates[0]) != 1 {
+		t.Fatalf("GeodesicCircle() = %s with %d polygons, want a single ring polygon", circle.Type, len(circle.Coordinates))
+	}
+
+	ring := circle.Coordinates[0][0]
+	if len(ring) != circleSegments+1 {
+		t.Fatalf("ring has %d positions, want %d", len(ring), circleSegments+1)
+	}
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		t.Errorf("ring is not closed: %v, %v", first, last)
+	}
+
+	signedArea := 0.0
+	for i, position := range ring[:circleSegments] {
+		distance := haversineDistance(paris, models.Point{Lng: position[0], Lat: position[1]})
+		if math.Abs(distance-radius) > 0.01 {
+			t.Errorf("vertex %d is %.2f m from the center, want %d m", i, distance, radius)
+		}
+		next := ring[i+1]
+		signedArea += position[0]*next[1] - next[0]*position[1]
+	}
+	if signedArea <= 0 {
+		t.Errorf("ring is clockwise, want counterclockwise")
+	}
+}
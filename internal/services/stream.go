@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+
+	"csv-processor/internal/models"
+)
+
+// StreamBusinesses calls emit with every business having any of the given NAF codes within the
+// GeoJSON geometry, as the scan finds them. It stops when ctx is done or emit fails and returns
+// the number of businesses emitted with the error.
+func (s *CSVService) StreamBusinesses(ctx context.Context, geojsonStr string, nafCodes []string, emit func(business *models.Business) error) (int, error) {
+	if ctx.Err() != nil {
+		return 0, contextError(ctx, "search")
+	}
+
+	geometry, err := s.convertGeoJSONToGeometry(geojsonStr)
+	if err != nil {
+		return 0, newServiceError(ErrorInvalidGeometry, "Invalid GeoJSON geometry", err)
+	}
+
+	polygons := models.NewPolygonSet(geometry)
+	if polygons.IsEmpty() {
+		return 0, nil
+	}
+	return s.streamBusinessesWithin(ctx, polygons.Envelope(), polygons.ContainsPoint, nafCodes, emit)
+}
+
+// StreamBusinessesInRadius calls emit with every business having any of the given NAF codes
+// within the given radius in meters of the center, as the scan finds them
+func (s *CSVService) StreamBusinessesInRadius(ctx context.Context, center models.Point, radius float64, nafCodes []string, emit func(business *models.Business) error) (int, error) {
+	if radius <= 0 {
+		return 0, newServiceError(ErrorInvalidInput, "Radius must be positive", nil).WithDetail("radius", radius)
+	}
+
+	if ctx.Err() != nil {
+		return 0, contextError(ctx, "search")
+	}
+
+	return s.streamBusinessesWithin(ctx, radiusEnvelope(center, radius), withinRadius(center, radius), nafCodes, emit)
+}
+
+// streamBusinessesWithin emits the matching businesses of the store, checking ctx every contextCheckInterval businesses
+func (s *CSVService) streamBusinessesWithin(ctx context.Context, envelope models.Envelope, contains func(lng, lat float64) bool, nafCodes []string, emit func(business *models.Business) error) (int, error) {
+	emitted := 0
+	err := s.businessStore.EachByNAFWithin(envelope, contains, nafCodes, func(business *models.Business) error {
+		if emitted%contextCheckInterval == 0 && ctx.Err() != nil {
+			return contextError(ctx, "search").WithDetail("businesses_found", emitted)
+		}
+		if err := emit(business); err != nil {
+			return err
+		}
+		emitted++
+		return nil
+	})
+	return emitted, err
+}
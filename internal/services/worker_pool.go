@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Run once the pool is closed
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// WorkerPool runs tasks on a fixed number of goroutines shared by all requests,
+// so concurrent requests queue their work instead of each spawning goroutines.
+type WorkerPool struct {
+	tasks   chan func()
+	size    int
+	workers sync.WaitGroup
+	// mu is held by Run while submitting, so Close waits for the submissions before closing tasks
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWorkerPool starts a pool of the given size, or of GOMAXPROCS workers when size is not positive
+func NewWorkerPool(size int) *WorkerPool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+
+	pool := &WorkerPool{
+		tasks: make(chan func(), size),
+		size:  size,
+	}
+	pool.workers.Add(size)
+	for i := 0; i < size; i++ {
+		go func() {
+			defer pool.workers.Done()
+			for task := range pool.tasks {
+				task()
+			}
+		}()
+	}
+	return pool
+}
+
+// Size returns the number of workers
+func (p *WorkerPool) Size() int {
+	return p.size
+}
+
+// Run calls task for every index in [0, n) on the pool and waits for the submitted calls.
+// It stops submitting when ctx is done and returns the context error in that case,
+// and returns ErrPoolClosed without calling task once the pool is closed.
+func (p *WorkerPool) Run(ctx context.Context, n int, task func(i int)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		select {
+		case p.tasks <- func() {
+			defer wg.Done()
+			task(i)
+		}:
+		case <-ctx.Done():
+			wg.Done()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close stops the workers once the queued tasks are done. Runs still submitting are waited for.
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+	p.workers.Wait()
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"csv-processor/internal/geomtest"
+	"csv-processor/internal/models"
+
+	geom2 "github.com/peterstace/simplefeatures/geom"
+)
+
+// benchmarkParallelCalls is the number of concurrent /iris-data requests simulated per operation
+const benchmarkParallelCalls = 10
+
+// benchmarkSquare returns a square of the given size in degrees with edgePoints vertices per edge
+func benchmarkSquare(minX, minY, size float64, edgePoints int) *geom2.Geometry {
+	coords := make([]float64, 0, (4*edgePoints+1)*2)
+	corners := [][2]float64{{minX, minY}, {minX + size, minY}, {minX + size, minY + size}, {minX, minY + size}}
+	for c := range corners {
+		start, end := corners[c], corners[(c+1)%len(corners)]
+		for i := 0; i < edgePoints; i++ {
+			t := float64(i) / float64(edgePoints)
+			coords = append(coords, start[0]+(end[0]-start[0])*t, start[1]+(end[1]-start[1])*t)
+		}
+	}
+	coords = append(coords, minX, minY)
+
+	ring := geom2.NewLineString(geom2.NewSequence(coords, geom2.DimXY))
+	polygon := geom2.NewPolygon([]geom2.LineString{ring}).AsGeometry()
+	return &polygon
+}
+
+// benchmarkIrisZones returns a grid of IRIS zones around Paris
+func benchmarkIrisZones(side int) []*models.IrisData {
+	const size = 0.01
+	zones := make([]*models.IrisData, 0, side*side)
+	for i := 0; i < side; i++ {
+		for j := 0; j < side; j++ {
+			polygon := benchmarkSquare(2.35-size*float64(side)/2+size*float64(i), 48.85-size*float64(side)/2+size*float64(j), size, 8)
+			zones = append(zones, &models.IrisData{
+				Polygon:          polygon,
+				ProjectedPolygon: projectPolygon(polygon),
+			})
+		}
+	}
+	return zones
+}
+
+// goroutinePerZoneIntersections is the previous GetIrisData loop: one goroutine per zone
+// and channels sized to the whole candidate list
+func goroutinePerZoneIntersections(projectedPolygon geom2.Geometry, irisData []*models.IrisData) []float64 {
+	type result struct {
+		index      int
+		percentage float64
+	}
+	results := make(chan result, len(irisData))
+	errors := make(chan error, len(irisData))
+
+	var wg sync.WaitGroup
+	for i, iris := range irisData {
+		wg.Add(1)
+		go func(i int, iris *models.IrisData) {
+			defer wg.Done()
+			if iris.Polygon == nil {
+				return
+			}
+			results <- result{index: i, percentage: calculateIntersectionPercentage(&projectedPolygon, iris.ProjectedPolygon)}
+		}(i, iris)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+		close(errors)
+	}()
+
+	percentages := make([]float64, len(irisData))
+	for result := range results {
+		percentages[result.index] = result.percentage
+	}
+	return percentages
+}
+
+// runParallelCalls runs the given call benchmarkParallelCalls times concurrently and waits for all of them
+func runParallelCalls(call func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < benchmarkParallelCalls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			call()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkIrisIntersections(b *testing.B) {
+	irisData := benchmarkIrisZones(100)
+	service := &CSVService{workerPool: NewWorkerPool(0)}
+	defer service.workerPool.Close()
+
+	for _, radius := range []float64{0.05, 0.2} {
+		projectedPolygon := models.ProjectToLambert93(geomtest.Circle(radius))
+
+		b.Run(fmt.Sprintf("goroutine-per-zone/radius=%g", radius), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				runParallelCalls(func() {
+					goroutinePerZoneIntersections(projectedPolygon, irisData)
+				})
+			}
+		})
+		b.Run(fmt.Sprintf("worker-pool/radius=%g", radius), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				runParallelCalls(func() {
+					service.irisIntersections(context.Background(), projectedPolygon, irisData)
+				})
+			}
+		})
+	}
+}
+
+func TestWorkerPoolRun(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.Close()
+
+	const n = 1000
+	var calls [n]int32
+	if err := pool.Run(context.Background(), n, func(i int) {
+		atomic.AddInt32(&calls[i], 1)
+	}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	for i, count := range calls {
+		if count != 1 {
+			t.Fatalf("task %d called %d times, want 1", i, count)
+		}
+	}
+}
+
+func TestWorkerPoolRunCancelled(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx, 100, func(i int) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				close(started)
+			}
+			<-release
+		})
+	}()
+
+	// The single worker is busy and the queue is full, so Run is blocked submitting
+	<-started
+	cancel()
+	close(release)
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after the context was cancelled")
+	}
+	if count := atomic.LoadInt32(&calls); count >= 100 {
+		t.Errorf("%d tasks called after cancellation, want fewer than 100", count)
+	}
+
+	// The pool keeps serving other runs
+	if err := pool.Run(context.Background(), 10, func(int) {}); err != nil {
+		t.Errorf("Run() after cancellation error = %v", err)
+	}
+}
+
+func TestWorkerPoolRunAfterClose(t *testing.T) {
+	pool := NewWorkerPool(2)
+	pool.Close()
+	// Closing twice is harmless
+	pool.Close()
+
+	called := false
+	if err := pool.Run(context.Background(), 10, func(int) { called = true }); err != ErrPoolClosed {
+		t.Errorf("Run() error = %v, want %v", err, ErrPoolClosed)
+	}
+	if called {
+		t.Error("Run() called a task on a closed pool")
+	}
+}